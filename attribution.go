@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// attributionOptions are the creator, source, year and notes command options
+// used when proposing or editing a spec.
+func attributionOptions() []*discordgo.ApplicationCommandOption {
+	return []*discordgo.ApplicationCommandOption{
+		{
+			Type:         discordgo.ApplicationCommandOptionString,
+			Name:         "creator",
+			Description:  "who created the drink",
+			Required:     false,
+			Autocomplete: true,
+		},
+		{
+			Type:         discordgo.ApplicationCommandOptionString,
+			Name:         "source",
+			Description:  "where the spec comes from, like a book, bar or URL",
+			Required:     false,
+			Autocomplete: true,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionInteger,
+			Name:        "year",
+			Description: "year the drink was created",
+			Required:    false,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "notes",
+			Description: "history or other notes on the drink",
+			Required:    false,
+		},
+	}
+}
+
+// year formats the year the drink was created, empty if it isn't known.
+func (s *spec) year() string {
+	if s.Year == 0 {
+		return ""
+	}
+	return strconv.Itoa(s.Year)
+}
+
+// knownAttributions returns the distinct values of field across the catalog,
+// sorted case insensitively.
+func knownAttributions(field func(*spec) string) []string {
+	seen := map[string]bool{}
+	var values []string
+	for _, cocktail := range catalog.names() {
+		sp, ok := catalog.spec(cocktail)
+		if !ok {
+			continue
+		}
+		v := field(sp)
+		if v == "" || seen[strings.ToLower(v)] {
+			continue
+		}
+		seen[strings.ToLower(v)] = true
+		values = append(values, v)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		return strings.ToLower(values[i]) < strings.ToLower(values[j])
+	})
+	return values
+}
+
+func specCreator(sp *spec) string { return sp.Creator }
+func specSource(sp *spec) string  { return sp.Source }
+
+// attributedTo returns the sorted cocktails whose field contains query,
+// ignoring case and spacing.
+func attributedTo(field func(*spec) string, query string) []string {
+	query = strings.Join(strings.Fields(strings.ToLower(query)), " ")
+	var matches []string
+	for _, cocktail := range catalog.names() {
+		sp, ok := catalog.spec(cocktail)
+		if !ok {
+			continue
+		}
+		v := strings.Join(strings.Fields(strings.ToLower(field(sp))), " ")
+		if v != "" && strings.Contains(v, query) {
+			matches = append(matches, cocktail)
+		}
+	}
+	return matches
+}
+
+// byCreator lists the cocktails created by the given person.
+func byCreator(ctx context.Context, store CatalogStore, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	listAttributed(s, i, "name", "created by", specCreator)
+}
+
+// bySource lists the cocktails from the given source.
+func bySource(ctx context.Context, store CatalogStore, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	listAttributed(s, i, "source", "from", specSource)
+}
+
+// listAttributed is the shared implementation of by-creator and by-source,
+// listing each match with the value it matched.
+func listAttributed(s *discordgo.Session, i *discordgo.InteractionCreate, option, verb string, field func(*spec) string) {
+	query := subcommandOptions(i)[option].StringValue()
+	var items []string
+	for _, c := range attributedTo(field, query) {
+		sp, _ := catalog.spec(c)
+		items = append(items, fmt.Sprintf("    %s - %s", c, field(sp)))
+	}
+	if len(items) == 0 {
+		respond(s, i.Interaction, fmt.Sprintf("No cocktails %s %q", verb, query), nil, true)
+		return
+	}
+	respondPages(s, i.Interaction, fmt.Sprintf("%d cocktails %s %q:\n", len(items), verb, query), items, true)
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// maxChoices is the most autocomplete choices Discord will accept.
+	maxChoices = 25
+	// maxChoiceLength is the longest name or value Discord allows a choice.
+	maxChoiceLength = 100
+)
+
+// choiceLabel shortens a choice name to fit maxChoiceLength.
+func choiceLabel(label string) string {
+	if r := []rune(label); len(r) > maxChoiceLength {
+		return string(r[:maxChoiceLength-1]) + "…"
+	}
+	return label
+}
+
+// focusedOption returns the option the user is currently typing in.
+func focusedOption(i *discordgo.InteractionCreate) *discordgo.ApplicationCommandInteractionDataOption {
+	for _, opt := range i.ApplicationCommandData().Options[0].Options {
+		if opt.Focused {
+			return opt
+		}
+	}
+	return nil
+}
+
+// suggestNames returns autocomplete choices from names ranked against query,
+// labels maps a name to how it should be shown if it differs. Names too long to
+// be a choice value, like long source URLs, are left out.
+func suggestNames(query string, names []string, labels map[string]string) []*discordgo.ApplicationCommandOptionChoice {
+	if query != "" {
+		var ranked []string
+		for _, m := range rankNames(query, names) {
+			if m.score < suggestScore {
+				break
+			}
+			ranked = append(ranked, m.name)
+		}
+		names = ranked
+	}
+	choices := []*discordgo.ApplicationCommandOptionChoice{}
+	for _, name := range names {
+		if len(choices) == maxChoices {
+			break
+		}
+		if utf8.RuneCountInString(name) > maxChoiceLength {
+			continue
+		}
+		label := name
+		if l, ok := labels[name]; ok {
+			label = l
+		}
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: choiceLabel(label), Value: name})
+	}
+	return choices
+}
+
+// pendingNames returns the keys of the pending proposals along with the
+// names they were submitted under.
+func pendingNames(a *waitingApproval) ([]string, map[string]string) {
+	var keys []string
+	labels := map[string]string{}
+	for _, p := range a.list() {
+		keys = append(keys, p.Key)
+		labels[p.Key] = p.Spec.Name
+	}
+	sort.Strings(keys)
+	return keys, labels
+}
+
+// autocomplete suggests cocktail names, tags, glasses, creators, sources,
+// the user's favorites and collections or pending proposals for the option
+// being typed.
+func autocomplete(ctx context.Context, store CatalogStore, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	opt := focusedOption(i)
+	if opt == nil {
+		return
+	}
+	query := opt.StringValue()
+
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	data := i.ApplicationCommandData()
+	switch data.Name + " " + data.Options[0].Name {
+	case "cocktail random", "cocktail tags", "cocktail filter", "cocktail top", "proposals create", "proposals edit":
+		switch opt.Name {
+		case "tag":
+			choices = suggestNames(query, sortedTags(), nil)
+		case "glass":
+			choices = suggestNames(query, knownGlasses(), nil)
+		case "creator":
+			choices = suggestNames(query, knownAttributions(specCreator), nil)
+		case "source":
+			choices = suggestNames(query, knownAttributions(specSource), nil)
+		case "name":
+			choices = suggestNames(query, catalog.names(), nil)
+		case "from":
+			choices = suggestNames(query, append([]string{fromFavorites}, collectionNames(ctx, store, interactionUser(i).ID)...), nil)
+		default:
+			return
+		}
+	case "collection delete", "collection add", "collection remove", "collection list":
+		switch opt.Name {
+		case "collection":
+			choices = suggestNames(query, collectionNames(ctx, store, interactionUser(i).ID), nil)
+		case "name":
+			choices = suggestNames(query, catalog.names(), nil)
+		default:
+			return
+		}
+	case "admin delete-note":
+		if opt.Name != "note" {
+			choices = suggestNames(query, catalog.names(), nil)
+			break
+		}
+		name, ok := subcommandOptions(i)["name"]
+		if !ok {
+			return
+		}
+		cocktail, _ := findCocktail(name.StringValue(), catalog.names(), catalog.aliasNames())
+		if cocktail == "" {
+			return
+		}
+		ids, labels, err := noteChoices(ctx, store, cocktail)
+		if err != nil {
+			log.Printf("Error reading tasting notes for autocomplete: %v", err)
+			return
+		}
+		choices = []*discordgo.ApplicationCommandOptionChoice{}
+		for _, id := range ids {
+			if strings.Contains(strings.ToLower(labels[id]), strings.ToLower(query)) || strings.HasPrefix(id, query) {
+				choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: labels[id], Value: id})
+			}
+		}
+	case "fav remove":
+		favs, err := getFavorites(ctx, store, interactionUser(i).ID)
+		if err != nil {
+			log.Printf("Error reading favorites for autocomplete: %v", err)
+			return
+		}
+		choices = suggestNames(query, favs, nil)
+	case "cocktail by-creator":
+		choices = suggestNames(query, knownAttributions(specCreator), nil)
+	case "cocktail by-source":
+		choices = suggestNames(query, knownAttributions(specSource), nil)
+	case "cocktail search", "cocktail rate", "cocktail batch", "cocktail history", "cocktail note", "cocktail notes", "fav add", "proposals create-variation", "admin rollback":
+		choices = suggestNames(query, catalog.names(), nil)
+	case "proposals approve", "proposals deny":
+		keys, labels := pendingNames(&waitingCreates)
+		choices = suggestNames(query, keys, labels)
+	case "proposals approve-variation", "proposals deny-variation":
+		keys, labels := pendingNames(&waitingVariations)
+		choices = suggestNames(query, keys, labels)
+	case "proposals approve-edit", "proposals deny-edit":
+		keys, labels := pendingNames(&waitingEdits)
+		choices = suggestNames(query, keys, labels)
+	default:
+		return
+	}
+
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+		Data: &discordgo.InteractionResponseData{
+			Choices: choices,
+		},
+	}); err != nil {
+		log.Printf("Error responding to autocomplete: %v", err)
+	}
+}
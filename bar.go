@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// staples are assumed to be in every bar.
+var staples = []string{"water", "ice"}
+
+func barKey(id string) string {
+	return path.Join(usersPrefix, id, "bar")
+}
+
+// getBar returns the user's bar inventory, stored at _meta/users/<id>/bar.
+func getBar(ctx context.Context, store CatalogStore, id string) ([]string, error) {
+	data, err := store.ReadObject(ctx, barKey(id))
+	if err == errNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var bar []string
+	return bar, json.Unmarshal(data, &bar)
+}
+
+func saveBar(ctx context.Context, store CatalogStore, id string, bar []string) error {
+	sort.Strings(bar)
+	data, err := json.Marshal(bar)
+	if err != nil {
+		return err
+	}
+	return store.WriteObject(ctx, barKey(id), data)
+}
+
+// splitItems splits a comma separated option into cleaned up, lower case items.
+func splitItems(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		item = strings.Join(strings.Fields(strings.ToLower(item)), " ")
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// wordsContain reports whether all the words of needle appear in order in
+// haystack, so "gin" matches "london dry gin" but not "ginger beer".
+func wordsContain(haystack, needle string) bool {
+	h := " " + nonWord.ReplaceAllString(strings.ToLower(haystack), " ") + " "
+	n := " " + strings.TrimSpace(nonWord.ReplaceAllString(strings.ToLower(needle), " ")) + " "
+	return n != "  " && strings.Contains(h, n)
+}
+
+// product returns the name used when matching an ingredient against a bar.
+func (ing ingredient) product() string {
+	if ing.Name != "" {
+		return ing.Name
+	}
+	return ing.Raw
+}
+
+// inBar reports whether any item in the bar satisfies the ingredient. A bar
+// item can be more specific than the ingredient, so "plymouth gin" covers
+// "gin" but "gin" doesn't cover "plymouth gin". Staples only match exactly,
+// "water" isn't "tonic water".
+func inBar(bar []string, ing ingredient) bool {
+	name := ing.product()
+	for _, item := range bar {
+		if wordsContain(item, name) {
+			return true
+		}
+	}
+	name = strings.Join(strings.Fields(nonWord.ReplaceAllString(strings.ToLower(name), " ")), " ")
+	for _, staple := range staples {
+		if name == staple {
+			return true
+		}
+	}
+	return false
+}
+
+// missingFromBar returns the ingredients of a variation not in the bar.
+func missingFromBar(bar []string, v variation) []ingredient {
+	var missing []ingredient
+	for _, ing := range v {
+		if !inBar(bar, ing) {
+			missing = append(missing, ing)
+		}
+	}
+	return missing
+}
+
+func barAdd(ctx context.Context, store CatalogStore, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	user := interactionUser(i)
+	bar, err := getBar(ctx, store, user.ID)
+	if err != nil {
+		logInteractionError(s, i.Interaction, err)
+		return
+	}
+	have := map[string]bool{}
+	for _, item := range bar {
+		have[item] = true
+	}
+	var added []string
+	for _, item := range splitItems(subcommandOptions(i)["items"].StringValue()) {
+		if have[item] {
+			continue
+		}
+		have[item] = true
+		bar = append(bar, item)
+		added = append(added, item)
+	}
+	if err := saveBar(ctx, store, user.ID, bar); err != nil {
+		logInteractionError(s, i.Interaction, err)
+		return
+	}
+	respond(s, i.Interaction, fmt.Sprintf("Added %d items to your bar: %s", len(added), strings.Join(added, ", ")), nil, true)
+}
+
+func barRemove(ctx context.Context, store CatalogStore, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	user := interactionUser(i)
+	bar, err := getBar(ctx, store, user.ID)
+	if err != nil {
+		logInteractionError(s, i.Interaction, err)
+		return
+	}
+	remove := map[string]bool{}
+	for _, item := range splitItems(subcommandOptions(i)["items"].StringValue()) {
+		remove[item] = true
+	}
+	var kept, removed []string
+	for _, item := range bar {
+		if remove[item] {
+			removed = append(removed, item)
+			continue
+		}
+		kept = append(kept, item)
+	}
+	if err := saveBar(ctx, store, user.ID, kept); err != nil {
+		logInteractionError(s, i.Interaction, err)
+		return
+	}
+	respond(s, i.Interaction, fmt.Sprintf("Removed %d items from your bar: %s", len(removed), strings.Join(removed, ", ")), nil, true)
+}
+
+func barList(ctx context.Context, store CatalogStore, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	bar, err := getBar(ctx, store, interactionUser(i).ID)
+	if err != nil {
+		logInteractionError(s, i.Interaction, err)
+		return
+	}
+	if len(bar) == 0 {
+		respond(s, i.Interaction, "Your bar is empty, add to it with /bar add", nil, true)
+		return
+	}
+	var items []string
+	for _, item := range bar {
+		items = append(items, "    "+item)
+	}
+	respondPages(s, i.Interaction, fmt.Sprintf("Your bar has %d items:\n", len(bar)), items, true)
+}
+
+func makeable(ctx context.Context, store CatalogStore, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: 1 << 6,
+		},
+	}); err != nil {
+		logInteractionError(s, i.Interaction, err)
+		return
+	}
+
+	bar, err := getBar(ctx, store, interactionUser(i).ID)
+	if err != nil {
+		logInteractionError(s, i.Interaction, err)
+		return
+	}
+
+	var canMake, oneAway []string
+	for _, cocktail := range catalog.names() {
+		sp, ok := catalog.spec(cocktail)
+		if !ok {
+			continue
+		}
+		for n, v := range sp.Ingredients {
+			name := cocktail
+			if len(sp.Ingredients) > 1 {
+				name = fmt.Sprintf("%s (variation %d)", cocktail, n+1)
+			}
+			switch missing := missingFromBar(bar, v); len(missing) {
+			case 0:
+				canMake = append(canMake, name)
+			case 1:
+				oneAway = append(oneAway, fmt.Sprintf("%s, missing %s", name, missing[0].product()))
+			}
+		}
+	}
+
+	var items []string
+	for _, c := range canMake {
+		items = append(items, "    "+c)
+	}
+	if len(oneAway) > 0 {
+		items = append(items, fmt.Sprintf("**%d cocktails are one ingredient away:**", len(oneAway)))
+		for _, c := range oneAway {
+			items = append(items, "    "+c)
+		}
+	}
+	editPages(s, i.Interaction, fmt.Sprintf("You can make %d cocktails:\n", len(canMake)), items)
+}
@@ -0,0 +1,24 @@
+package main
+
+import "testing"
+
+func TestInBar(t *testing.T) {
+	for _, tc := range []struct {
+		bar  []string
+		raw  string
+		want bool
+	}{
+		{nil, "2 oz tonic water", false},
+		{nil, "1 oz coconut water", false},
+		{nil, "1 scoop vanilla ice cream", false},
+		{nil, "1 oz water", true},
+		{[]string{"plymouth gin"}, "2 oz gin", true},
+		{[]string{"gin"}, "2 oz plymouth gin", false},
+		{[]string{"gin"}, "4 oz ginger beer", false},
+		{[]string{"tonic water"}, "2 oz tonic water", true},
+	} {
+		if got := inBar(tc.bar, parseIngredient(tc.raw)); got != tc.want {
+			t.Errorf("inBar(%q, %q) = %v, want %v", tc.bar, tc.raw, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Limits on the batch options, a thousand servings is already more than any
+// party needs and anything past 100% dilution is mostly water.
+const (
+	maxServings = 1000
+	maxDilution = 100
+)
+
+var minServings, minDilution = 1.0, 0.0
+
+// batchVariation scales a variation to the given number of servings and
+// returns the scaled ingredients along with the water needed to match the
+// dilution of a freshly mixed drink.
+func batchVariation(v variation, servings int, dilution float64) (variation, ingredient) {
+	var scaled variation
+	var totalML float64
+	// Express the water in whichever unit the spec mostly uses.
+	unitCount := map[string]int{}
+	for _, ing := range v {
+		s := ing.scale(float64(servings))
+		scaled = append(scaled, s)
+		if ml := s.ml(); ml > 0 {
+			totalML += ml
+			unitCount[s.Unit]++
+		}
+	}
+	waterUnit := "ml"
+	for _, u := range []string{"oz", "cl", "ml"} {
+		if unitCount[u] > unitCount[waterUnit] {
+			waterUnit = u
+		}
+	}
+	u, _ := lookupUnit(waterUnit)
+	water := ingredient{
+		Amount: totalML * dilution / 100 / u.ml,
+		Unit:   waterUnit,
+		Name:   "water",
+	}
+	water.Raw = water.describe()
+	return scaled, water
+}
+
+func batch(ctx context.Context, store CatalogStore, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	opts := subcommandOptions(i)
+	name := opts["name"].StringValue()
+	servings := int(opts["servings"].IntValue())
+	// A negative dilution means estimate it from how the drink is mixed.
+	dilution := -1.0
+	if d, ok := opts["dilution"]; ok {
+		dilution = float64(d.IntValue())
+	}
+	if servings < 1 || servings > maxServings {
+		respond(s, i.Interaction, fmt.Sprintf("Servings must be from 1 to %d", maxServings), nil, true)
+		return
+	}
+	if _, ok := opts["dilution"]; ok && (dilution < 0 || dilution > maxDilution) {
+		respond(s, i.Interaction, fmt.Sprintf("Dilution must be from 0 to %d%%", maxDilution), nil, true)
+		return
+	}
+
+	cocktails, err := listCocktails(ctx, store)
+	if err != nil {
+		logInteractionError(s, i.Interaction, err)
+		return
+	}
+	found, suggestions := findCocktail(name, cocktails, catalog.aliasNames())
+	if found == "" {
+		respond(s, i.Interaction, didYouMean(name, suggestions), nil, true)
+		return
+	}
+
+	sp, err := getSpec(ctx, store, found)
+	if err != nil {
+		logInteractionError(s, i.Interaction, err)
+		return
+	}
+
+	method := specMethod(sp)
+	header := fmt.Sprintf("**%s, batched for %d servings**\n", sp.Name, servings)
+	// One item per variation so pages only break between them.
+	var items []string
+	for n, v := range sp.Ingredients {
+		var content string
+		d := dilution
+		if d < 0 {
+			d = math.Round(estimateStrength(v, method).Dilution * 100)
+		}
+		scaled, water := batchVariation(v, servings, d)
+		if len(sp.Ingredients) > 1 {
+			content = fmt.Sprintf("\n*Variation %d:*\n", n+1)
+		}
+		content = fmt.Sprintf("%s%s%% dilution\n", content, formatAmount(d))
+		var unparsed bool
+		for _, ing := range scaled {
+			if !ing.parsed() {
+				unparsed = true
+				content = fmt.Sprintf("%s%s (not scaled)\n", content, ing)
+				continue
+			}
+			content = fmt.Sprintf("%s%s\n", content, ing)
+		}
+		if water.Amount > 0 {
+			content = fmt.Sprintf("%s%s\n", content, water)
+		}
+		if unparsed {
+			content += "Some ingredients couldn't be read and weren't scaled, adjust those by hand.\n"
+		}
+		items = append(items, strings.TrimSuffix(content, "\n"))
+	}
+	respondPages(s, i.Interaction, header, items, false)
+}
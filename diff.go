@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// noChanges is the diff of identical specs.
+const noChanges = "No changes"
+
+// maxDiffLength keeps a diff and the text around it within a message.
+const maxDiffLength = 1500
+
+// diffLines is a line diff of a against b from their longest common
+// subsequence, kept lines are indented and changed lines are prefixed with -
+// or +.
+func diffLines(a, b []string) []string {
+	// lcs[x][y] is the length of the longest common subsequence of a[x:] and
+	// b[y:].
+	lcs := make([][]int, len(a)+1)
+	for x := range lcs {
+		lcs[x] = make([]int, len(b)+1)
+	}
+	for x := len(a) - 1; x >= 0; x-- {
+		for y := len(b) - 1; y >= 0; y-- {
+			if a[x] == b[y] {
+				lcs[x][y] = lcs[x+1][y+1] + 1
+			} else if lcs[x+1][y] >= lcs[x][y+1] {
+				lcs[x][y] = lcs[x+1][y]
+			} else {
+				lcs[x][y] = lcs[x][y+1]
+			}
+		}
+	}
+
+	var out []string
+	x, y := 0, 0
+	for x < len(a) || y < len(b) {
+		switch {
+		case x < len(a) && y < len(b) && a[x] == b[y]:
+			out = append(out, "  "+a[x])
+			x++
+			y++
+		case y == len(b) || (x < len(a) && lcs[x+1][y] >= lcs[x][y+1]):
+			out = append(out, "- "+a[x])
+			x++
+		default:
+			out = append(out, "+ "+b[y])
+			y++
+		}
+	}
+	return out
+}
+
+func ingredientLines(v variation) []string {
+	var lines []string
+	for _, ing := range v {
+		lines = append(lines, ing.String())
+	}
+	return lines
+}
+
+// specDiff describes the changes from old to updated field by field, leaving
+// out fields that are unchanged. It is meant to be shown in a diff code block.
+func specDiff(old, updated *spec) string {
+	var b strings.Builder
+	if old.Name != updated.Name {
+		fmt.Fprintf(&b, "Name:\n- %s\n+ %s\n", old.Name, updated.Name)
+	}
+	for n := 0; n < len(old.Ingredients) || n < len(updated.Ingredients); n++ {
+		var a, c variation
+		if n < len(old.Ingredients) {
+			a = old.Ingredients[n]
+		}
+		if n < len(updated.Ingredients) {
+			c = updated.Ingredients[n]
+		}
+		al, cl := ingredientLines(a), ingredientLines(c)
+		if strings.Join(al, "\n") == strings.Join(cl, "\n") {
+			continue
+		}
+		fmt.Fprintf(&b, "Ingredients, variation %d:\n%s\n", n+1, strings.Join(diffLines(al, cl), "\n"))
+	}
+	if strings.Join(old.Aliases, "\n") != strings.Join(updated.Aliases, "\n") {
+		fmt.Fprintf(&b, "Aliases:\n%s\n", strings.Join(diffLines(old.Aliases, updated.Aliases), "\n"))
+	}
+	for _, f := range []struct{ name, old, updated string }{
+		{"Garnish", old.Garnish, updated.Garnish},
+		{"Glass", old.Glass, updated.Glass},
+		{"Method", old.Method, updated.Method},
+		{"Ice", old.Ice, updated.Ice},
+		{"Creator", old.Creator, updated.Creator},
+		{"Year", old.year(), updated.year()},
+		{"Source", old.Source, updated.Source},
+		{"Notes", old.Notes, updated.Notes},
+	} {
+		if f.old != f.updated {
+			fmt.Fprintf(&b, "%s:\n- %s\n+ %s\n", f.name, f.old, f.updated)
+		}
+	}
+	if strings.Join(old.Instructions, "\n") != strings.Join(updated.Instructions, "\n") {
+		fmt.Fprintf(&b, "Instructions:\n%s\n", strings.Join(diffLines(old.Instructions, updated.Instructions), "\n"))
+	}
+	if strings.Join(old.Tags, ",") != strings.Join(updated.Tags, ",") {
+		fmt.Fprintf(&b, "Tags:\n- %s\n+ %s\n", strings.Join(old.Tags, ", "), strings.Join(updated.Tags, ", "))
+	}
+	if b.Len() == 0 {
+		return noChanges
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// truncate shortens s to at most n characters, marking that it was cut.
+func truncate(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n-2]) + "\n…"
+	}
+	return s
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSpecDiff(t *testing.T) {
+	parse := func(raws ...string) variation {
+		var v variation
+		for _, raw := range raws {
+			v = append(v, parseIngredient(raw))
+		}
+		return v
+	}
+	old := &spec{
+		Name:         "Negroni",
+		Ingredients:  []variation{parse("1 oz gin", "1 oz Campari", "1 oz sweet vermouth")},
+		Garnish:      "orange peel",
+		Instructions: []string{"Stir with ice", "Strain over a large cube"},
+	}
+	for _, tc := range []struct {
+		desc   string
+		update func(*spec)
+		want   []string
+	}{
+		{"unchanged", func(*spec) {}, []string{noChanges}},
+		{"garnish", func(sp *spec) { sp.Garnish = "lemon peel" }, []string{"Garnish:\n- orange peel\n+ lemon peel"}},
+		{"ingredient", func(sp *spec) {
+			sp.Ingredients = []variation{parse("1 oz gin", "1 oz Aperol", "1 oz sweet vermouth")}
+		}, []string{"Ingredients, variation 1:\n  1 oz gin\n- 1 oz Campari\n+ 1 oz Aperol\n  1 oz sweet vermouth"}},
+		{"added variation", func(sp *spec) {
+			sp.Ingredients = append(sp.Ingredients, parse("1 oz bourbon"))
+		}, []string{"Ingredients, variation 2:\n+ 1 oz bourbon"}},
+		{"instructions", func(sp *spec) {
+			sp.Instructions = []string{"Stir with ice", "Strain into a coupe"}
+		}, []string{"Instructions:\n  Stir with ice\n- Strain over a large cube\n+ Strain into a coupe"}},
+		{"several fields", func(sp *spec) {
+			sp.Name = "Negroni Sbagliato"
+			sp.Tags = []string{"bitter"}
+		}, []string{"Name:\n- Negroni\n+ Negroni Sbagliato", "Tags:\n- \n+ bitter"}},
+	} {
+		updated := *old
+		tc.update(&updated)
+		got := specDiff(old, &updated)
+		for _, want := range tc.want {
+			if !strings.Contains(got, want) {
+				t.Errorf("%s: specDiff() = %q, want it to contain %q", tc.desc, got, want)
+			}
+		}
+		if tc.desc != "garnish" && strings.Contains(got, "Garnish:") {
+			t.Errorf("%s: specDiff() = %q, unchanged fields should be left out", tc.desc, got)
+		}
+	}
+}
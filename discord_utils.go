@@ -7,17 +7,36 @@ import (
 )
 
 func logInteractionError(s *discordgo.Session, i *discordgo.Interaction, err error) {
-	s.FollowupMessageCreate(s.State.User.ID, i, true, &discordgo.WebhookParams{
+	s.FollowupMessageCreate(i, true, &discordgo.WebhookParams{
 		Content: "Something went wrong",
 		Flags:   1 << 6,
 	})
 	log.Print(err)
 }
 
+// interactionUser returns the user who triggered the interaction, whether it
+// happened in a guild or a DM.
+func interactionUser(i *discordgo.InteractionCreate) *discordgo.User {
+	if i.Member != nil {
+		return i.Member.User
+	}
+	return i.User
+}
+
+// subcommandOptions returns the options passed to the invoked subcommand,
+// keyed by option name.
+func subcommandOptions(i *discordgo.InteractionCreate) map[string]*discordgo.ApplicationCommandInteractionDataOption {
+	opts := map[string]*discordgo.ApplicationCommandInteractionDataOption{}
+	for _, opt := range i.ApplicationCommandData().Options[0].Options {
+		opts[opt.Name] = opt
+	}
+	return opts
+}
+
 func respond(s *discordgo.Session, i *discordgo.Interaction, content string, files []*discordgo.File, ephemeral bool) bool {
-	flags := uint64(0)
+	var flags discordgo.MessageFlags
 	if ephemeral {
-		flags = 1 << 6
+		flags = discordgo.MessageFlagsEphemeral
 	}
 	if err := s.InteractionRespond(i, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
@@ -33,16 +52,48 @@ func respond(s *discordgo.Session, i *discordgo.Interaction, content string, fil
 	return true
 }
 
+// respondEmbed responds with embeds, any pictures referenced by the embeds
+// must be passed as files.
+func respondEmbed(s *discordgo.Session, i *discordgo.Interaction, embeds []*discordgo.MessageEmbed, files []*discordgo.File, ephemeral bool) bool {
+	for _, e := range embeds {
+		fitEmbed(e)
+	}
+	var flags discordgo.MessageFlags
+	if ephemeral {
+		flags = discordgo.MessageFlagsEphemeral
+	}
+	if err := s.InteractionRespond(i, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags:  flags,
+			Embeds: embeds,
+			Files:  files,
+		},
+	}); err != nil {
+		logInteractionError(s, i, err)
+		return false
+	}
+	return true
+}
+
 func dm(s *discordgo.Session, id, content string) {
+	dmComplex(s, id, &discordgo.MessageSend{Content: content})
+}
+
+// dmComplex sends a DM with components or embeds, returning nil if sending
+// failed.
+func dmComplex(s *discordgo.Session, id string, data *discordgo.MessageSend) *discordgo.Message {
 	// We create the private channel with the user who sent the message.
 	channel, err := s.UserChannelCreate(id)
 	if err != nil {
 		log.Println("error creating channel:", err)
-		return
+		return nil
 	}
 	// Then we send the message through the channel we created.
-	_, err = s.ChannelMessageSend(channel.ID, content)
+	m, err := s.ChannelMessageSendComplex(channel.ID, data)
 	if err != nil {
 		log.Println("error sending dm:", err)
+		return nil
 	}
+	return m
 }
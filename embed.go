@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	embedColor = 0xb5651d
+	// maxFieldValue is the longest value Discord allows in an embed field.
+	maxFieldValue = 1024
+	// maxFields and maxEmbedLength are Discord's limits on the number of
+	// fields in an embed and the total characters across it.
+	maxFields      = 25
+	maxEmbedLength = 6000
+	// maxVariationFields is how many variations get a field of their own,
+	// any more are merged into one so there's room for the rest of the spec.
+	maxVariationFields = 6
+)
+
+// unsafeAttachment matches characters Discord rewrites in attachment file
+// names, which would break attachment:// references to them.
+var unsafeAttachment = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// attachmentName cleans up a file name so it can be referenced from an
+// embed once attached.
+func attachmentName(name string) string {
+	if name = unsafeAttachment.ReplaceAllString(name, "_"); name == "" || name == "." {
+		return "picture"
+	}
+	return name
+}
+
+func truncateField(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "None"
+	}
+	if r := []rune(s); len(r) > maxFieldValue {
+		return string(r[:maxFieldValue-1]) + "…"
+	}
+	return s
+}
+
+// embed renders the spec as a rich embed with pours converted to the given
+// unit system. If pic is set it is used as the embed image, it must also be
+// attached to the message.
+func (s *spec) embed(system unitSystem, pic *discordgo.File) *discordgo.MessageEmbed {
+	e := &discordgo.MessageEmbed{
+		Title: s.Name,
+		Color: embedColor,
+	}
+	if len(s.Aliases) > 0 {
+		e.Description = aliasesPrefix + strings.Join(s.Aliases, ", ")
+	}
+	var rest string
+	for n, v := range s.Ingredients {
+		var ingredients string
+		for _, ing := range v {
+			ingredients = fmt.Sprintf("%s%s\n", ingredients, ing.in(system))
+		}
+		if len(s.Ingredients) > maxVariationFields && n >= maxVariationFields-1 {
+			rest = fmt.Sprintf("%s*Variation %d:*\n%s\n", rest, n+1, ingredients)
+			continue
+		}
+		name := "Ingredients"
+		if len(s.Ingredients) > 1 {
+			name = fmt.Sprintf("Variation %d", n+1)
+		}
+		e.Fields = append(e.Fields, &discordgo.MessageEmbedField{
+			Name:   name,
+			Value:  truncateField(ingredients),
+			Inline: len(s.Ingredients) > 1,
+		})
+	}
+	if rest != "" {
+		e.Fields = append(e.Fields, &discordgo.MessageEmbedField{
+			Name:  fmt.Sprintf("Variations %d to %d", maxVariationFields, len(s.Ingredients)),
+			Value: truncateField(rest),
+		})
+	}
+
+	var instructions string
+	for _, i := range s.Instructions {
+		instructions = fmt.Sprintf("%s%s\n", instructions, strings.TrimSpace(i))
+	}
+	e.Fields = append(e.Fields, &discordgo.MessageEmbedField{Name: "Garnish", Value: truncateField(s.Garnish)})
+	for _, f := range [][2]string{{"Glass", s.Glass}, {"Method", s.Method}, {"Ice", s.Ice}} {
+		if f[1] != "" {
+			e.Fields = append(e.Fields, &discordgo.MessageEmbedField{Name: f[0], Value: truncateField(f[1]), Inline: true})
+		}
+	}
+	e.Fields = append(e.Fields, &discordgo.MessageEmbedField{Name: "Instructions", Value: truncateField(instructions)})
+	for _, f := range [][2]string{{"Created by", s.Creator}, {"Year", s.year()}, {"Source", s.Source}} {
+		if f[1] != "" {
+			e.Fields = append(e.Fields, &discordgo.MessageEmbedField{Name: f[0], Value: truncateField(f[1]), Inline: true})
+		}
+	}
+	if s.Notes != "" {
+		e.Fields = append(e.Fields, &discordgo.MessageEmbedField{Name: "Notes", Value: truncateField(s.Notes)})
+	}
+
+	if len(s.Tags) > 0 {
+		e.Footer = &discordgo.MessageEmbedFooter{Text: tagsPrefix + strings.Join(s.Tags, ", ")}
+	}
+
+	if pic != nil {
+		e.Image = &discordgo.MessageEmbedImage{URL: "attachment://" + pic.Name}
+	}
+	return e
+}
+
+// embedLength counts the characters Discord counts towards maxEmbedLength.
+func embedLength(e *discordgo.MessageEmbed) int {
+	n := utf8.RuneCountInString(e.Title) + utf8.RuneCountInString(e.Description)
+	if e.Footer != nil {
+		n += utf8.RuneCountInString(e.Footer.Text)
+	}
+	for _, f := range e.Fields {
+		n += utf8.RuneCountInString(f.Name) + utf8.RuneCountInString(f.Value)
+	}
+	return n
+}
+
+// fitEmbed drops fields from the end of an embed until it is within
+// Discord's limits, the spec itself comes first so it is kept.
+func fitEmbed(e *discordgo.MessageEmbed) {
+	if len(e.Fields) > maxFields {
+		e.Fields = e.Fields[:maxFields]
+	}
+	for len(e.Fields) > 0 && embedLength(e) > maxEmbedLength {
+		e.Fields = e.Fields[:len(e.Fields)-1]
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEmbedManyVariations(t *testing.T) {
+	sp := &spec{Name: "Daiquiri", Garnish: "lime", Instructions: []string{"shake"}}
+	for n := 0; n < 40; n++ {
+		sp.Ingredients = append(sp.Ingredients, variation{parseIngredient("2 oz rum " + strings.Repeat("x", 200))})
+	}
+	e := sp.embed(unitsOriginal, nil)
+	fitEmbed(e)
+	if len(e.Fields) > maxFields {
+		t.Errorf("embed has %d fields, want at most %d", len(e.Fields), maxFields)
+	}
+	if n := embedLength(e); n > maxEmbedLength {
+		t.Errorf("embed is %d characters, want at most %d", n, maxEmbedLength)
+	}
+}
+
+func TestAttachmentName(t *testing.T) {
+	for in, want := range map[string]string{
+		"daiquiri.jpg":        "daiquiri.jpg",
+		"my daiquiri (1).jpg": "my_daiquiri_1_.jpg",
+		"":                    "picture",
+	} {
+		if got := attachmentName(in); got != want {
+			t.Errorf("attachmentName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
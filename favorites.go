@@ -0,0 +1,358 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// fromFavorites is the /cocktail random from: value picking from the user's
+// favorites rather than a collection.
+const fromFavorites = "favorites"
+
+// collection is a named list of cocktails a user put together.
+type collection struct {
+	Name      string
+	Cocktails []string
+}
+
+func favoritesKey(id string) string {
+	return path.Join(usersPrefix, id, "favorites")
+}
+
+func collectionsKey(id string) string {
+	return path.Join(usersPrefix, id, "collections")
+}
+
+// getFavorites returns the user's favorite cocktails, stored at
+// _meta/users/<id>/favorites.
+func getFavorites(ctx context.Context, store CatalogStore, id string) ([]string, error) {
+	data, err := store.ReadObject(ctx, favoritesKey(id))
+	if err == errNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var favs []string
+	return favs, json.Unmarshal(data, &favs)
+}
+
+func saveFavorites(ctx context.Context, store CatalogStore, id string, favs []string) error {
+	sort.Strings(favs)
+	data, err := json.Marshal(favs)
+	if err != nil {
+		return err
+	}
+	return store.WriteObject(ctx, favoritesKey(id), data)
+}
+
+// getCollections returns the user's collections, stored at
+// _meta/users/<id>/collections.
+func getCollections(ctx context.Context, store CatalogStore, id string) ([]*collection, error) {
+	data, err := store.ReadObject(ctx, collectionsKey(id))
+	if err == errNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cols []*collection
+	return cols, json.Unmarshal(data, &cols)
+}
+
+func saveCollections(ctx context.Context, store CatalogStore, id string, cols []*collection) error {
+	sort.Slice(cols, func(i, j int) bool {
+		return strings.ToLower(cols[i].Name) < strings.ToLower(cols[j].Name)
+	})
+	data, err := json.Marshal(cols)
+	if err != nil {
+		return err
+	}
+	return store.WriteObject(ctx, collectionsKey(id), data)
+}
+
+// findCollection returns the collection with the name, ignoring case and
+// spacing, or nil.
+func findCollection(cols []*collection, name string) *collection {
+	name = strings.Join(strings.Fields(strings.ToLower(name)), " ")
+	for _, c := range cols {
+		if strings.Join(strings.Fields(strings.ToLower(c.Name)), " ") == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// collectionNames returns the names of the user's collections for
+// autocomplete, or nil if they can't be read.
+func collectionNames(ctx context.Context, store CatalogStore, id string) []string {
+	cols, err := getCollections(ctx, store, id)
+	if err != nil {
+		log.Printf("Error reading collections for %q: %v", id, err)
+		return nil
+	}
+	var names []string
+	for _, c := range cols {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+// randomFrom returns the cocktails in the user's favorites or the named
+// collection that are still in the catalog, or a message for the user if
+// there are none.
+func randomFrom(ctx context.Context, store CatalogStore, id, from string) ([]string, string, error) {
+	var list []string
+	if strings.EqualFold(strings.TrimSpace(from), fromFavorites) {
+		favs, err := getFavorites(ctx, store, id)
+		if err != nil {
+			return nil, "", err
+		}
+		list = favs
+	} else {
+		cols, err := getCollections(ctx, store, id)
+		if err != nil {
+			return nil, "", err
+		}
+		c := findCollection(cols, from)
+		if c == nil {
+			return nil, fmt.Sprintf("You don't have a collection called %q, see /collection list", from), nil
+		}
+		list = c.Cocktails
+	}
+	var cocktails []string
+	for _, cocktail := range list {
+		if _, ok := catalog.spec(cocktail); ok {
+			cocktails = append(cocktails, cocktail)
+		}
+	}
+	if len(cocktails) == 0 {
+		return nil, fmt.Sprintf("There are no cocktails in %s yet", from), nil
+	}
+	return cocktails, "", nil
+}
+
+// resolveCocktail finds the cocktail the user named, responding with
+// suggestions if there isn't one.
+func resolveCocktail(ctx context.Context, store CatalogStore, s *discordgo.Session, i *discordgo.InteractionCreate, name string) (string, bool) {
+	cocktails, err := listCocktails(ctx, store)
+	if err != nil {
+		logInteractionError(s, i.Interaction, err)
+		return "", false
+	}
+	found, suggestions := findCocktail(name, cocktails, catalog.aliasNames())
+	if found == "" {
+		respond(s, i.Interaction, didYouMean(name, suggestions), nil, true)
+		return "", false
+	}
+	return found, true
+}
+
+func favAdd(ctx context.Context, store CatalogStore, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	found, ok := resolveCocktail(ctx, store, s, i, subcommandOptions(i)["name"].StringValue())
+	if !ok {
+		return
+	}
+	user := interactionUser(i)
+	favs, err := getFavorites(ctx, store, user.ID)
+	if err != nil {
+		logInteractionError(s, i.Interaction, err)
+		return
+	}
+	favs, added := addUnique(favs, found)
+	if !added {
+		respond(s, i.Interaction, fmt.Sprintf("%s is already a favorite", found), nil, true)
+		return
+	}
+	if err := saveFavorites(ctx, store, user.ID, favs); err != nil {
+		logInteractionError(s, i.Interaction, err)
+		return
+	}
+	respond(s, i.Interaction, fmt.Sprintf("Added %s to your favorites", found), nil, true)
+}
+
+func favRemove(ctx context.Context, store CatalogStore, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	name := subcommandOptions(i)["name"].StringValue()
+	user := interactionUser(i)
+	favs, err := getFavorites(ctx, store, user.ID)
+	if err != nil {
+		logInteractionError(s, i.Interaction, err)
+		return
+	}
+	// Favorites may have since been removed from the catalog, so match them
+	// directly before looking the name up.
+	found, _ := findCocktail(name, favs, nil)
+	favs, removed := removeValue(favs, found)
+	if !removed {
+		respond(s, i.Interaction, fmt.Sprintf("%s isn't one of your favorites", name), nil, true)
+		return
+	}
+	if err := saveFavorites(ctx, store, user.ID, favs); err != nil {
+		logInteractionError(s, i.Interaction, err)
+		return
+	}
+	respond(s, i.Interaction, fmt.Sprintf("Removed %s from your favorites", found), nil, true)
+}
+
+func favList(ctx context.Context, store CatalogStore, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	favs, err := getFavorites(ctx, store, interactionUser(i).ID)
+	if err != nil {
+		logInteractionError(s, i.Interaction, err)
+		return
+	}
+	if len(favs) == 0 {
+		respond(s, i.Interaction, "You have no favorites yet, add some with /fav add", nil, true)
+		return
+	}
+	var items []string
+	for _, f := range favs {
+		items = append(items, "    "+f)
+	}
+	respondPages(s, i.Interaction, fmt.Sprintf("You have %d favorites:\n", len(favs)), items, true)
+}
+
+func collectionCreate(ctx context.Context, store CatalogStore, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	name := strings.Join(strings.Fields(subcommandOptions(i)["collection"].StringValue()), " ")
+	if name == "" || strings.EqualFold(name, fromFavorites) {
+		respond(s, i.Interaction, fmt.Sprintf("%q can't be used as a collection name", name), nil, true)
+		return
+	}
+	user := interactionUser(i)
+	cols, err := getCollections(ctx, store, user.ID)
+	if err != nil {
+		logInteractionError(s, i.Interaction, err)
+		return
+	}
+	if findCollection(cols, name) != nil {
+		respond(s, i.Interaction, fmt.Sprintf("You already have a collection called %q", name), nil, true)
+		return
+	}
+	cols = append(cols, &collection{Name: name})
+	if err := saveCollections(ctx, store, user.ID, cols); err != nil {
+		logInteractionError(s, i.Interaction, err)
+		return
+	}
+	respond(s, i.Interaction, fmt.Sprintf("Created %q, add cocktails with /collection add", name), nil, true)
+}
+
+func collectionDelete(ctx context.Context, store CatalogStore, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	name := subcommandOptions(i)["collection"].StringValue()
+	user := interactionUser(i)
+	cols, err := getCollections(ctx, store, user.ID)
+	if err != nil {
+		logInteractionError(s, i.Interaction, err)
+		return
+	}
+	c := findCollection(cols, name)
+	if c == nil {
+		respond(s, i.Interaction, fmt.Sprintf("You don't have a collection called %q", name), nil, true)
+		return
+	}
+	var kept []*collection
+	for _, other := range cols {
+		if other != c {
+			kept = append(kept, other)
+		}
+	}
+	if err := saveCollections(ctx, store, user.ID, kept); err != nil {
+		logInteractionError(s, i.Interaction, err)
+		return
+	}
+	respond(s, i.Interaction, fmt.Sprintf("Deleted %q", c.Name), nil, true)
+}
+
+func collectionAdd(ctx context.Context, store CatalogStore, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	opts := subcommandOptions(i)
+	user := interactionUser(i)
+	cols, err := getCollections(ctx, store, user.ID)
+	if err != nil {
+		logInteractionError(s, i.Interaction, err)
+		return
+	}
+	c := findCollection(cols, opts["collection"].StringValue())
+	if c == nil {
+		respond(s, i.Interaction, fmt.Sprintf("You don't have a collection called %q, create it with /collection create", opts["collection"].StringValue()), nil, true)
+		return
+	}
+	found, ok := resolveCocktail(ctx, store, s, i, opts["name"].StringValue())
+	if !ok {
+		return
+	}
+	var added bool
+	if c.Cocktails, added = addUnique(c.Cocktails, found); !added {
+		respond(s, i.Interaction, fmt.Sprintf("%s is already in %q", found, c.Name), nil, true)
+		return
+	}
+	sort.Strings(c.Cocktails)
+	if err := saveCollections(ctx, store, user.ID, cols); err != nil {
+		logInteractionError(s, i.Interaction, err)
+		return
+	}
+	respond(s, i.Interaction, fmt.Sprintf("Added %s to %q", found, c.Name), nil, true)
+}
+
+func collectionRemove(ctx context.Context, store CatalogStore, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	opts := subcommandOptions(i)
+	name := opts["name"].StringValue()
+	user := interactionUser(i)
+	cols, err := getCollections(ctx, store, user.ID)
+	if err != nil {
+		logInteractionError(s, i.Interaction, err)
+		return
+	}
+	c := findCollection(cols, opts["collection"].StringValue())
+	if c == nil {
+		respond(s, i.Interaction, fmt.Sprintf("You don't have a collection called %q", opts["collection"].StringValue()), nil, true)
+		return
+	}
+	found, _ := findCocktail(name, c.Cocktails, nil)
+	var removed bool
+	if c.Cocktails, removed = removeValue(c.Cocktails, found); !removed {
+		respond(s, i.Interaction, fmt.Sprintf("%s isn't in %q", name, c.Name), nil, true)
+		return
+	}
+	if err := saveCollections(ctx, store, user.ID, cols); err != nil {
+		logInteractionError(s, i.Interaction, err)
+		return
+	}
+	respond(s, i.Interaction, fmt.Sprintf("Removed %s from %q", found, c.Name), nil, true)
+}
+
+// collectionList lists the user's collections, or the cocktails in one if
+// it is given.
+func collectionList(ctx context.Context, store CatalogStore, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	cols, err := getCollections(ctx, store, interactionUser(i).ID)
+	if err != nil {
+		logInteractionError(s, i.Interaction, err)
+		return
+	}
+	if opt, ok := subcommandOptions(i)["collection"]; ok {
+		c := findCollection(cols, opt.StringValue())
+		if c == nil {
+			respond(s, i.Interaction, fmt.Sprintf("You don't have a collection called %q", opt.StringValue()), nil, true)
+			return
+		}
+		var items []string
+		for _, cocktail := range c.Cocktails {
+			items = append(items, "    "+cocktail)
+		}
+		respondPages(s, i.Interaction, fmt.Sprintf("%q has %d cocktails:\n", c.Name, len(c.Cocktails)), items, true)
+		return
+	}
+	if len(cols) == 0 {
+		respond(s, i.Interaction, "You have no collections yet, start one with /collection create", nil, true)
+		return
+	}
+	var items []string
+	for _, c := range cols {
+		items = append(items, fmt.Sprintf("    %s (%d cocktails)", c.Name, len(c.Cocktails)))
+	}
+	respondPages(s, i.Interaction, fmt.Sprintf("You have %d collections:\n", len(cols)), items, true)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// cocktailFilter narrows down the catalog, empty fields match everything.
+type cocktailFilter struct {
+	Tag string
+	// Base is a base spirit, matching a tag or an ingredient of any
+	// variation.
+	Base   string
+	Glass  string
+	Method string
+	Ice    string
+}
+
+// filterOptions reads a filter from the options of the invoked subcommand.
+func filterOptions(i *discordgo.InteractionCreate) cocktailFilter {
+	var f cocktailFilter
+	for name, opt := range subcommandOptions(i) {
+		switch name {
+		case "tag":
+			f.Tag = normalizeTag(opt.StringValue())
+		case "base":
+			f.Base = opt.StringValue()
+		case "glass":
+			f.Glass = normalizeGlass(opt.StringValue())
+		case "method":
+			f.Method = opt.StringValue()
+		case "ice":
+			f.Ice = opt.StringValue()
+		}
+	}
+	return f
+}
+
+func (f cocktailFilter) empty() bool {
+	return f == cocktailFilter{}
+}
+
+// String describes the filter for replies.
+func (f cocktailFilter) String() string {
+	var parts []string
+	for _, p := range [][2]string{{"tag", f.Tag}, {"base", f.Base}, {"glass", f.Glass}, {"method", f.Method}, {"ice", f.Ice}} {
+		if p[1] != "" {
+			parts = append(parts, fmt.Sprintf("%s %q", p[0], p[1]))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// cocktails returns the sorted cocktails matching the filter.
+func (f cocktailFilter) cocktails() []string {
+	var withBase map[string]bool
+	if f.Base != "" {
+		withBase = map[string]bool{}
+		for ref := range catalog.lookup(f.Base) {
+			withBase[ref.cocktail] = true
+		}
+	}
+	baseTag := normalizeTag(f.Base)
+
+	var matches []string
+	for _, cocktail := range catalog.names() {
+		sp, ok := catalog.spec(cocktail)
+		if !ok {
+			continue
+		}
+		switch {
+		case f.Tag != "" && !sp.hasTag(f.Tag),
+			f.Base != "" && !withBase[cocktail] && !sp.hasTag(baseTag),
+			f.Glass != "" && sp.Glass != f.Glass,
+			f.Method != "" && sp.Method != f.Method,
+			f.Ice != "" && sp.Ice != f.Ice:
+			continue
+		}
+		matches = append(matches, cocktail)
+	}
+	return matches
+}
+
+// filter lists the cocktails matching the given options.
+func filter(ctx context.Context, store CatalogStore, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	f := filterOptions(i)
+	if f.empty() {
+		respond(s, i.Interaction, "Give at least one thing to filter by", nil, true)
+		return
+	}
+	var items []string
+	for _, c := range f.cocktails() {
+		items = append(items, "    "+c)
+	}
+	respondPages(s, i.Interaction, fmt.Sprintf("%d cocktails with %s:\n", len(items), f), items, true)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// commandWith is a subcommand interaction with the given string options.
+func commandWith(opts map[string]string) *discordgo.InteractionCreate {
+	sub := &discordgo.ApplicationCommandInteractionDataOption{Type: discordgo.ApplicationCommandOptionSubCommand, Name: "filter"}
+	for name, value := range opts {
+		sub.Options = append(sub.Options, &discordgo.ApplicationCommandInteractionDataOption{
+			Type:  discordgo.ApplicationCommandOptionString,
+			Name:  name,
+			Value: value,
+		})
+	}
+	return &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		Type: discordgo.InteractionApplicationCommand,
+		Data: discordgo.ApplicationCommandInteractionData{Name: "cocktail", Options: []*discordgo.ApplicationCommandInteractionDataOption{sub}},
+	}}
+}
+
+func TestFilterOptions(t *testing.T) {
+	for _, tc := range []struct {
+		opts map[string]string
+		want cocktailFilter
+	}{
+		{nil, cocktailFilter{}},
+		{map[string]string{"tag": " Low ABV"}, cocktailFilter{Tag: "low-abv"}},
+		{map[string]string{"glass": "Nick  &  Nora", "method": mixStir}, cocktailFilter{Glass: "nick & nora", Method: mixStir}},
+		{map[string]string{"base": "rye", "ice": "crushed", "name": "ignored"}, cocktailFilter{Base: "rye", Ice: "crushed"}},
+	} {
+		if got := filterOptions(commandWith(tc.opts)); got != tc.want {
+			t.Errorf("filterOptions(%v) = %+v, want %+v", tc.opts, got, tc.want)
+		}
+	}
+}
+
+func TestFilterString(t *testing.T) {
+	for _, tc := range []struct {
+		f    cocktailFilter
+		want string
+	}{
+		{cocktailFilter{}, ""},
+		{cocktailFilter{Tag: "sour"}, `tag "sour"`},
+		{cocktailFilter{Base: "gin", Glass: "coupe", Ice: "none"}, `base "gin", glass "coupe", ice "none"`},
+	} {
+		if got := tc.f.String(); got != tc.want {
+			t.Errorf("%+v.String() = %q, want %q", tc.f, got, tc.want)
+		}
+		if got := tc.f.empty(); got != (tc.want == "") {
+			t.Errorf("%+v.empty() = %v, want %v", tc.f, got, tc.want == "")
+		}
+	}
+}
+
+func TestFilterCocktails(t *testing.T) {
+	defer func(c *catalogIndex) { catalog = c }(catalog)
+	catalog = newCatalogIndex()
+	for _, sp := range []*spec{
+		{Name: "Gimlet", Ingredients: []variation{{parseIngredient("2 oz gin"), parseIngredient("3/4 oz lime cordial")}}, Glass: "coupe", Method: mixShake, Ice: "none", Tags: []string{"sour"}},
+		{Name: "Negroni", Ingredients: []variation{{parseIngredient("1 oz gin"), parseIngredient("1 oz Campari"), parseIngredient("1 oz sweet vermouth")}}, Glass: "rocks", Method: mixStir, Ice: "large cube"},
+		{Name: "Mai Tai", Ingredients: []variation{{parseIngredient("2 oz aged rum"), parseIngredient("3/4 oz lime juice")}}, Glass: "rocks", Method: mixShake, Ice: "crushed", Tags: []string{"sour", "tiki"}},
+		{Name: "Mojito", Ingredients: []variation{{parseIngredient("2 oz white rum")}}, Glass: "highball", Method: mixBuild, Ice: "crushed", Tags: []string{"rum"}},
+	} {
+		catalog.update(sp.Name, sp)
+	}
+
+	for _, tc := range []struct {
+		f    cocktailFilter
+		want []string
+	}{
+		{cocktailFilter{}, []string{"Gimlet", "Mai Tai", "Mojito", "Negroni"}},
+		{cocktailFilter{Tag: "sour"}, []string{"Gimlet", "Mai Tai"}},
+		{cocktailFilter{Base: "gin"}, []string{"Gimlet", "Negroni"}},
+		{cocktailFilter{Base: "rum"}, []string{"Mai Tai", "Mojito"}},
+		{cocktailFilter{Glass: "rocks", Method: mixShake}, []string{"Mai Tai"}},
+		{cocktailFilter{Ice: "crushed", Tag: "tiki"}, []string{"Mai Tai"}},
+		{cocktailFilter{Glass: "flute"}, nil},
+	} {
+		if got := tc.f.cocktails(); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%+v.cocktails() = %q, want %q", tc.f, got, tc.want)
+		}
+	}
+}
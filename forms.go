@@ -0,0 +1,252 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// proposalFormID prefixes the custom ID of the modal opened by /proposals
+	// create, which looks like proposal-form:<interaction ID>.
+	proposalFormID = "proposal-form"
+	// editFormID prefixes the custom ID of the modal opened by /proposals edit,
+	// which looks like proposal-edit:<interaction ID>. The cocktail being
+	// edited is kept in its draft, custom IDs are too short for long names.
+	editFormID = "proposal-edit"
+	// draftTimeout is how long a form can stay open before the options given
+	// with its command are forgotten.
+	draftTimeout = time.Hour
+)
+
+// formDraft holds the spec details given as options to the command that
+// opened a form until the form is submitted, Discord modals only fit five
+// inputs. For edits it also holds the cocktail being edited.
+type formDraft struct {
+	cocktail string
+	details  *spec
+	expires  time.Time
+}
+
+type formDrafts struct {
+	drafts map[string]*formDraft
+	sync.Mutex
+}
+
+var drafts = formDrafts{drafts: map[string]*formDraft{}}
+
+// save keeps the details for the form opened by an interaction, clearing out
+// any drafts that have expired.
+func (d *formDrafts) save(id, cocktail string, details *spec) {
+	d.Lock()
+	defer d.Unlock()
+	now := time.Now()
+	for k, draft := range d.drafts {
+		if now.After(draft.expires) {
+			delete(d.drafts, k)
+		}
+	}
+	d.drafts[id] = &formDraft{cocktail: cocktail, details: details, expires: now.Add(draftTimeout)}
+}
+
+// take returns and forgets the cocktail and details for the form opened by an
+// interaction, details is nil if there are none.
+func (d *formDrafts) take(id string) (string, *spec) {
+	d.Lock()
+	defer d.Unlock()
+	draft, ok := d.drafts[id]
+	if !ok {
+		return "", nil
+	}
+	delete(d.drafts, id)
+	return draft.cocktail, draft.details
+}
+
+// formDetails returns the spec details given as options to /proposals create
+// or edit, starting from base. i may be nil to just copy base.
+func formDetails(i *discordgo.InteractionCreate, base *spec) *spec {
+	details := &spec{}
+	if base != nil {
+		details.copyDetails(base)
+	}
+	if i == nil {
+		return details
+	}
+	opts := subcommandOptions(i)
+	if opt, ok := opts["tags"]; ok {
+		details.Tags = splitTags(opt.StringValue())
+	}
+	if opt, ok := opts["glass"]; ok {
+		details.Glass = normalizeGlass(opt.StringValue())
+	}
+	if opt, ok := opts["method"]; ok {
+		details.Method = opt.StringValue()
+	}
+	if opt, ok := opts["ice"]; ok {
+		details.Ice = opt.StringValue()
+	}
+	if opt, ok := opts["creator"]; ok {
+		details.Creator = strings.TrimSpace(opt.StringValue())
+	}
+	if opt, ok := opts["source"]; ok {
+		details.Source = strings.TrimSpace(opt.StringValue())
+	}
+	if opt, ok := opts["year"]; ok {
+		details.Year = int(opt.IntValue())
+	}
+	if opt, ok := opts["notes"]; ok {
+		details.Notes = strings.TrimSpace(opt.StringValue())
+	}
+	return details
+}
+
+// copyDetails copies the fields that aren't part of the proposal forms.
+func (s *spec) copyDetails(from *spec) {
+	s.Tags = from.Tags
+	s.Glass = from.Glass
+	s.Method = from.Method
+	s.Ice = from.Ice
+	s.Creator = from.Creator
+	s.Source = from.Source
+	s.Year = from.Year
+	s.Notes = from.Notes
+}
+
+// listMarker matches bullets and numbering people tend to type at the start
+// of a line, "1.5 oz" is left alone as the marker must be followed by a space.
+var listMarker = regexp.MustCompile(`^(?:[-*•]|\d+[.)])\s+`)
+
+// blankLine separates variations in the edit form.
+var blankLine = regexp.MustCompile(`\n\s*\n`)
+
+// formInput is a single text input row of a modal.
+func formInput(id, label string, style discordgo.TextInputStyle, placeholder, value string, required bool) discordgo.MessageComponent {
+	return discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+		discordgo.TextInput{
+			CustomID:    id,
+			Label:       label,
+			Style:       style,
+			Placeholder: placeholder,
+			Value:       value,
+			Required:    required,
+			MaxLength:   4000,
+		},
+	}}
+}
+
+// proposalForm is the modal for proposing a new spec, id is the ID of the
+// interaction opening it.
+func proposalForm(id string) *discordgo.InteractionResponseData {
+	return &discordgo.InteractionResponseData{
+		CustomID: proposalFormID + ":" + id,
+		Title:    "Propose a cocktail",
+		Components: []discordgo.MessageComponent{
+			formInput("name", "Name", discordgo.TextInputShort, "Negroni", "", true),
+			formInput("ingredients", "Ingredients, one per line", discordgo.TextInputParagraph, "1 oz gin\n1 oz Campari\n1 oz sweet vermouth", "", true),
+			formInput("instructions", "Instructions, one step per line", discordgo.TextInputParagraph, "Stir with ice\nStrain over a large cube", "", true),
+			formInput("garnish", "Garnish", discordgo.TextInputShort, "Orange peel", "", false),
+			formInput("aliases", "Other names it goes by, one per line", discordgo.TextInputParagraph, "", "", false),
+		},
+	}
+}
+
+// editForm is the modal for proposing changes to a stored spec, pre-filled
+// with the current spec. Variations are separated by blank lines.
+func editForm(id string, sp *spec) *discordgo.InteractionResponseData {
+	var variations []string
+	for _, v := range sp.Ingredients {
+		var lines []string
+		for _, ing := range v {
+			lines = append(lines, ing.String())
+		}
+		variations = append(variations, strings.Join(lines, "\n"))
+	}
+	title := "Edit " + sp.Name
+	if len(title) > 45 {
+		// Discord's limit for modal titles.
+		title = "Edit a cocktail"
+	}
+	return &discordgo.InteractionResponseData{
+		CustomID: editFormID + ":" + id,
+		Title:    title,
+		Components: []discordgo.MessageComponent{
+			formInput("name", "Name", discordgo.TextInputShort, "", sp.Name, true),
+			formInput("ingredients", "Ingredients, blank line between variations", discordgo.TextInputParagraph, "", strings.Join(variations, "\n\n"), true),
+			formInput("instructions", "Instructions, one step per line", discordgo.TextInputParagraph, "", strings.Join(sp.Instructions, "\n"), true),
+			formInput("garnish", "Garnish", discordgo.TextInputShort, "", sp.Garnish, false),
+			formInput("aliases", "Other names it goes by, one per line", discordgo.TextInputParagraph, "", strings.Join(sp.Aliases, "\n"), false),
+		},
+	}
+}
+
+// modalValues returns the values of the text inputs in a submitted modal,
+// keyed by custom ID.
+func modalValues(data discordgo.ModalSubmitInteractionData) map[string]string {
+	values := map[string]string{}
+	for _, c := range data.Components {
+		row, ok := c.(*discordgo.ActionsRow)
+		if !ok {
+			continue
+		}
+		for _, c := range row.Components {
+			if input, ok := c.(*discordgo.TextInput); ok {
+				values[input.CustomID] = input.Value
+			}
+		}
+	}
+	return values
+}
+
+// formLines splits a multi-line text input into its non-empty lines, with any
+// list markers removed.
+func formLines(text string) []string {
+	var lines []string
+	for _, l := range strings.Split(text, "\n") {
+		l = listMarker.ReplaceAllString(strings.TrimSpace(l), "")
+		if l != "" {
+			lines = append(lines, l)
+		}
+	}
+	return lines
+}
+
+// formVariations splits the ingredients of the edit form into variations on
+// blank lines.
+func formVariations(text string) []variation {
+	var vs []variation
+	for _, block := range blankLine.Split(text, -1) {
+		if v := parseVariation(formLines(block)); len(v) > 0 {
+			vs = append(vs, v)
+		}
+	}
+	return vs
+}
+
+// parseProposalForm builds the proposed spec from a submitted proposal or edit
+// form, details holds anything given outside the form and may be nil. Blank
+// ingredient lines are dropped, so a spec without any has no variations.
+func parseProposalForm(data discordgo.ModalSubmitInteractionData, details *spec) *spec {
+	values := modalValues(data)
+	garnish := strings.TrimSpace(values["garnish"])
+	if garnish == "" {
+		garnish = "None"
+	}
+	sp := &spec{
+		Name:         strings.TrimSpace(values["name"]),
+		Aliases:      formLines(values["aliases"]),
+		Instructions: formLines(values["instructions"]),
+		Garnish:      garnish,
+	}
+	if strings.HasPrefix(data.CustomID, editFormID+":") {
+		sp.Ingredients = formVariations(values["ingredients"])
+	} else if v := parseVariation(formLines(values["ingredients"])); len(v) > 0 {
+		sp.Ingredients = []variation{v}
+	}
+	if details != nil {
+		sp.copyDetails(details)
+	}
+	return sp
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	// confidentScore is the score a match needs to be picked without asking.
+	confidentScore = 0.85
+	// confidentLead is how far the best match must be ahead of the next.
+	confidentLead = 0.1
+	// suggestScore is the lowest score worth offering as a suggestion.
+	suggestScore   = 0.4
+	maxSuggestions = 5
+)
+
+type nameMatch struct {
+	name  string
+	score float64
+}
+
+// fuzzyKey reduces a name to lower case letters and digits so punctuation and
+// spacing differences don't count against a match.
+func fuzzyKey(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, s)
+}
+
+// editDistance returns the optimal string alignment distance between a and
+// b, which counts swapped neighbouring letters as a single edit.
+func editDistance(a, b []rune) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = minInt(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = minInt(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
+
+func minInt(v int, vs ...int) int {
+	for _, x := range vs {
+		if x < v {
+			v = x
+		}
+	}
+	return v
+}
+
+func trigrams(s string) map[string]bool {
+	r := []rune("  " + s + " ")
+	grams := map[string]bool{}
+	for i := 0; i+3 <= len(r); i++ {
+		grams[string(r[i:i+3])] = true
+	}
+	return grams
+}
+
+// similarity scores how well name matches query from 0 to 1, taking the best
+// of edit distance, trigram overlap and a bonus for containing the query.
+func similarity(query, name string) float64 {
+	q, n := fuzzyKey(query), fuzzyKey(name)
+	if q == "" || n == "" {
+		return 0
+	}
+	if q == n {
+		return 1
+	}
+
+	qr, nr := []rune(q), []rune(n)
+	longest := len(qr)
+	if len(nr) > longest {
+		longest = len(nr)
+	}
+	score := 1 - float64(editDistance(qr, nr))/float64(longest)
+
+	qg, ng := trigrams(q), trigrams(n)
+	var shared int
+	for g := range qg {
+		if ng[g] {
+			shared++
+		}
+	}
+	if tri := float64(shared) / float64(len(qg)+len(ng)-shared); tri > score {
+		score = tri
+	}
+
+	if strings.Contains(n, q) {
+		if partial := 0.8 + 0.2*float64(len(qr))/float64(len(nr)); partial > score {
+			score = partial
+		}
+	}
+	return score
+}
+
+// rankNames scores every name against query, best first.
+func rankNames(query string, names []string) []nameMatch {
+	var ranked []nameMatch
+	for _, name := range names {
+		ranked = append(ranked, nameMatch{name: name, score: similarity(query, name)})
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].score > ranked[j].score
+	})
+	return ranked
+}
+
+// rankCocktails ranks cocktails against query by their best scoring name or
+// alias, aliases maps each alias to its cocktail.
+func rankCocktails(query string, names []string, aliases map[string]string) []nameMatch {
+	candidates := append([]string(nil), names...)
+	var extra []string
+	for alias := range aliases {
+		extra = append(extra, alias)
+	}
+	// Sorted so ties are broken the same way every time.
+	sort.Strings(extra)
+	candidates = append(candidates, extra...)
+
+	var ranked []nameMatch
+	seen := map[string]bool{}
+	for _, m := range rankNames(query, candidates) {
+		if cocktail, ok := aliases[m.name]; ok {
+			m.name = cocktail
+		}
+		if !seen[m.name] {
+			seen[m.name] = true
+			ranked = append(ranked, m)
+		}
+	}
+	return ranked
+}
+
+// findCocktail returns the cocktail name best matching query, by name or
+// alias, if it is a confident match, otherwise it returns a ranked list of
+// suggestions.
+func findCocktail(query string, names []string, aliases map[string]string) (string, []string) {
+	ranked := rankCocktails(query, names, aliases)
+	if len(ranked) == 0 {
+		return "", nil
+	}
+	best := ranked[0]
+	if best.score == 1 {
+		return best.name, nil
+	}
+	if best.score >= confidentScore && (len(ranked) == 1 || best.score-ranked[1].score >= confidentLead) {
+		return best.name, nil
+	}
+	var suggestions []string
+	for _, m := range ranked {
+		if m.score < suggestScore || len(suggestions) == maxSuggestions {
+			break
+		}
+		suggestions = append(suggestions, m.name)
+	}
+	return "", suggestions
+}
+
+// duplicateOf returns the existing cocktail that name is the same as, going
+// by its name or its aliases and ignoring punctuation, or "".
+func duplicateOf(name string, names []string, aliases map[string]string) string {
+	key := fuzzyKey(name)
+	if key == "" {
+		return ""
+	}
+	for _, n := range names {
+		if fuzzyKey(n) == key {
+			return n
+		}
+	}
+	for alias, cocktail := range aliases {
+		if fuzzyKey(alias) == key {
+			return cocktail
+		}
+	}
+	return ""
+}
+
+// didYouMean formats suggestions from findCocktail for a reply.
+func didYouMean(query string, suggestions []string) string {
+	if len(suggestions) == 0 {
+		return fmt.Sprintf("No matches, for %q", query)
+	}
+	return "Did you mean:\n" + strings.Join(suggestions, "\n")
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+var testCocktails = []string{"Negroni", "Boulevardier", "Old Fashioned", "Daiquiri", "Hemingway Daiquiri", "Martini", "Manhattan", "Margarita", "Mai Tai"}
+
+func TestEditDistance(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want int
+	}{
+		{"negroni", "negroni", 0},
+		{"negroni", "negorni", 1},
+		{"negroni", "negrni", 1},
+		{"kitten", "sitting", 3},
+		{"abc", "", 3},
+		{"", "", 0},
+	} {
+		if got := editDistance([]rune(tc.a), []rune(tc.b)); got != tc.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	for _, tc := range []struct {
+		query, name string
+		want        float64
+	}{
+		{"gin", "gin", 1},
+		{"Mai-Tai", "mai tai", 1},
+		{"", "gin", 0},
+		{"xyz", "negroni", 0},
+		{"daiq", "daiquiri", 0.9},
+	} {
+		if got := similarity(tc.query, tc.name); got != tc.want {
+			t.Errorf("similarity(%q, %q) = %v, want %v", tc.query, tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestFindCocktail(t *testing.T) {
+	for _, tc := range []struct {
+		query       string
+		want        string
+		suggestions []string
+	}{
+		{"negroni", "Negroni", nil},
+		{"Negorni", "Negroni", nil},
+		{"old fashoined", "Old Fashioned", nil},
+		{"oldfashioned", "Old Fashioned", nil},
+		{"boulvardier", "Boulevardier", nil},
+		{"hemingway", "Hemingway Daiquiri", nil},
+		{"daiq", "", []string{"Daiquiri", "Hemingway Daiquiri"}},
+		{"mar", "", []string{"Martini", "Margarita"}},
+		{"martinez", "", []string{"Martini"}},
+		{"xyz", "", nil},
+		{"", "", nil},
+	} {
+		got, suggestions := findCocktail(tc.query, testCocktails, nil)
+		if got != tc.want || len(suggestions)+len(tc.suggestions) > 0 && !reflect.DeepEqual(suggestions, tc.suggestions) {
+			t.Errorf("findCocktail(%q) = %q, %q, want %q, %q", tc.query, got, suggestions, tc.want, tc.suggestions)
+		}
+	}
+}
+
+var testAliases = map[string]string{
+	"Hemingway Special":         "Hemingway Daiquiri",
+	"Papa Doble":                "Hemingway Daiquiri",
+	"Improved Whiskey Cocktail": "Old Fashioned",
+}
+
+func TestFindCocktailAliases(t *testing.T) {
+	for _, tc := range []struct {
+		query string
+		want  string
+	}{
+		{"papa doble", "Hemingway Daiquiri"},
+		{"papa dobel", "Hemingway Daiquiri"},
+		{"hemingway special", "Hemingway Daiquiri"},
+		{"improved whiskey cocktail", "Old Fashioned"},
+		{"negroni", "Negroni"},
+	} {
+		if got, suggestions := findCocktail(tc.query, testCocktails, testAliases); got != tc.want {
+			t.Errorf("findCocktail(%q) = %q, %q, want %q", tc.query, got, suggestions, tc.want)
+		}
+	}
+}
+
+func TestDuplicateOf(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		want string
+	}{
+		{"Papa Doble", "Hemingway Daiquiri"},
+		{"papa-doble", "Hemingway Daiquiri"},
+		{"MAI TAI", "Mai Tai"},
+		{"Old  Fashioned", "Old Fashioned"},
+		{"Martinez", ""},
+		{"!!!", ""},
+	} {
+		if got := duplicateOf(tc.name, testCocktails, testAliases); got != tc.want {
+			t.Errorf("duplicateOf(%q) = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
@@ -2,11 +2,14 @@ module github.com/adjackura/c3-bot
 
 go 1.17
 
-require github.com/bwmarrin/discordgo v0.23.3-0.20210821175000-0fad116c6c2a
+require (
+	cloud.google.com/go/storage v1.16.1
+	github.com/bwmarrin/discordgo v0.27.1
+	google.golang.org/api v0.57.0
+)
 
 require (
 	cloud.google.com/go v0.94.1 // indirect
-	cloud.google.com/go/storage v1.16.1 // indirect
 	github.com/golang/groupcache v0.0.0-20200121045136-8c9f03a8e57e // indirect
 	github.com/golang/protobuf v1.5.2 // indirect
 	github.com/google/go-cmp v0.5.6 // indirect
@@ -18,7 +21,6 @@ require (
 	golang.org/x/oauth2 v0.0.0-20210819190943-2bc19b11175f // indirect
 	golang.org/x/sys v0.0.0-20210927094055-39ccf1dd6fa6 // indirect
 	golang.org/x/text v0.3.6 // indirect
-	google.golang.org/api v0.57.0 // indirect
 	google.golang.org/appengine v1.6.7 // indirect
 	google.golang.org/genproto v0.0.0-20210903162649-d08c68adba83 // indirect
 	google.golang.org/grpc v1.40.0 // indirect
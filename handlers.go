@@ -2,100 +2,68 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
-	"path"
 	"strings"
 
-	"cloud.google.com/go/storage"
 	"github.com/bwmarrin/discordgo"
 )
 
-func list(ctx context.Context, client *storage.Client, s *discordgo.Session, i *discordgo.InteractionCreate) {
-	cocktails, err := listCocktails(ctx, client)
+func list(ctx context.Context, store CatalogStore, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	cocktails, err := listCocktails(ctx, store)
 	if err != nil {
 		logInteractionError(s, i.Interaction, err)
 		return
 	}
 
-	var content string
+	var items []string
 	for _, cocktail := range cocktails {
-		content = fmt.Sprintf("%s    %s\n", content, cocktail)
+		items = append(items, "    "+cocktail)
 	}
-	respond(s, i.Interaction, fmt.Sprintf("I currently know about %d cocktails:\n%s", len(cocktails), content), nil, true)
+	respondPages(s, i.Interaction, fmt.Sprintf("I currently know about %d cocktails:\n", len(cocktails)), items, true)
 }
 
-func search(ctx context.Context, client *storage.Client, s *discordgo.Session, i *discordgo.InteractionCreate) {
-	name := i.ApplicationCommandData().Options[0].Options[0].StringValue()
-	cocktails, err := listCocktails(ctx, client)
+func search(ctx context.Context, store CatalogStore, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	name := subcommandOptions(i)["name"].StringValue()
+	cocktails, err := listCocktails(ctx, store)
 	if err != nil {
 		logInteractionError(s, i.Interaction, err)
 		return
 	}
 
-	// Find exact matches first
-	for _, cocktail := range cocktails {
-		if normalizeName(cocktail) == normalizeName(name) {
-			var files []*discordgo.File
-			sp, pic, closer, err := getCocktail(ctx, client, cocktail)
-			if err != nil {
-				logInteractionError(s, i.Interaction, err)
-				return
-			}
-			if pic != nil {
-				defer closer()
-				files = []*discordgo.File{
-					pic,
-				}
-			}
-			respond(s, i.Interaction, sp.String(), files, false)
-			return
-		}
-	}
-	// If we got here that means that the name is not an exact match, try partial matches
-	var matches []string
-	for _, cocktail := range cocktails {
-		if strings.Contains(normalizeName(cocktail), normalizeName(name)) {
-			matches = append(matches, cocktail)
-		}
+	found, suggestions := findCocktail(name, cocktails, catalog.aliasNames())
+	if found == "" {
+		respond(s, i.Interaction, didYouMean(name, suggestions), nil, true)
+		return
 	}
 
-	// No matches
-	if len(matches) == 0 {
-		respond(s, i.Interaction, fmt.Sprintf("No matches, for %q", name), nil, true)
+	var files []*discordgo.File
+	sp, pic, closer, err := getCocktail(ctx, store, found)
+	if err != nil {
+		logInteractionError(s, i.Interaction, err)
 		return
 	}
-
-	// One match
-	if len(matches) == 1 {
-		var files []*discordgo.File
-		sp, pic, closer, err := getCocktail(ctx, client, matches[0])
-		if err != nil {
-			logInteractionError(s, i.Interaction, err)
-			return
-		}
-		if pic != nil {
-			defer closer()
-			files = []*discordgo.File{
-				pic,
-			}
+	if pic != nil {
+		defer closer()
+		files = []*discordgo.File{
+			pic,
 		}
-		respond(s, i.Interaction, sp.String(), files, false)
-		return
 	}
-
-	// Multiple matches
-	var content string
-	for _, match := range matches {
-		content = fmt.Sprintf("%s%s\n", content, match)
+	units := unitsFor(ctx, store, i)
+	e := sp.embed(units, pic)
+	e.Fields = append(e.Fields, &discordgo.MessageEmbedField{Name: "Strength", Value: truncateField(strengthSummary(sp, units))})
+	e.Fields = append(e.Fields, &discordgo.MessageEmbedField{Name: "Rating", Value: ratingSummary(found)})
+	notes, err := recentNotes(ctx, store, found)
+	if err != nil {
+		logInteractionError(s, i.Interaction, err)
+		return
 	}
-	respond(s, i.Interaction, "Multiple matches:\n"+content, nil, true)
+	e.Fields = append(e.Fields, &discordgo.MessageEmbedField{Name: "Tasting notes", Value: truncateField(notes)})
+	respondEmbed(s, i.Interaction, []*discordgo.MessageEmbed{e}, files, false)
 }
 
-func searchIngredients(ctx context.Context, client *storage.Client, s *discordgo.Session, i *discordgo.InteractionCreate) {
+func searchIngredients(ctx context.Context, store CatalogStore, s *discordgo.Session, i *discordgo.InteractionCreate) {
 	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
@@ -107,33 +75,24 @@ func searchIngredients(ctx context.Context, client *storage.Client, s *discordgo
 	}
 
 	ingredients := strings.Split(i.ApplicationCommandData().Options[0].Options[0].StringValue(), ",")
-	cocktails, err := listCocktails(ctx, client)
-	if err != nil {
-		logInteractionError(s, i.Interaction, err)
-		return
+	// Count how many of the wanted ingredients each variation has, a cocktail
+	// is as good a match as its best variation.
+	counts := map[variationRef]int{}
+	for _, wantI := range ingredients {
+		for ref := range catalog.lookup(wantI) {
+			counts[ref]++
+		}
+	}
+	best := map[string]int{}
+	for ref, n := range counts {
+		if n > best[ref.cocktail] {
+			best[ref.cocktail] = n
+		}
 	}
 	var fullMatches []string
 	var partialMatches []string
-	for _, cocktail := range cocktails {
-		sp, err := getSpec(ctx, client, cocktail)
-		if err != nil {
-			logInteractionError(s, i.Interaction, err)
-			continue
-		}
-		var matches int
-		for _, wantI := range ingredients {
-			wantI = strings.ToLower(wantI)
-			for _, v := range sp.Ingredients {
-				for _, ci := range v {
-					ci = strings.ToLower(ci)
-					if strings.Contains(ci, wantI) {
-						matches++
-						break
-					}
-				}
-			}
-		}
-		if matches == len(ingredients) {
+	for _, cocktail := range catalog.names() {
+		if matches := best[cocktail]; matches == len(ingredients) {
 			fullMatches = append(fullMatches, cocktail)
 		} else if matches > 0 {
 			partialMatches = append(partialMatches, cocktail)
@@ -142,100 +101,104 @@ func searchIngredients(ctx context.Context, client *storage.Client, s *discordgo
 
 	if len(fullMatches) == 0 && len(partialMatches) == 0 {
 		content := fmt.Sprintf("Seach for cocktails containing %q resulted in no matches", ingredients)
-		if _, err := s.InteractionResponseEdit(s.State.User.ID, i.Interaction, &discordgo.WebhookEdit{
-			Content: content,
+		if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+			Content: &content,
 		}); err != nil {
 			logInteractionError(s, i.Interaction, err)
 		}
 		return
 	}
 
-	content := fmt.Sprintf("Seach for cocktails containing %q resulted in %d full matches and %d partial matches:", ingredients, len(fullMatches), len(partialMatches))
+	header := fmt.Sprintf("Seach for cocktails containing %q resulted in %d full matches and %d partial matches:\n", ingredients, len(fullMatches), len(partialMatches))
+	var items []string
 	if len(fullMatches) > 0 {
-		content = fmt.Sprintf("%s**%d full matches:**\n", content, len(fullMatches))
+		items = append(items, fmt.Sprintf("**%d full matches:**", len(fullMatches)))
 		for _, c := range fullMatches {
-			content = fmt.Sprintf("%s    %s\n", content, c)
+			items = append(items, "    "+c)
 		}
 	}
 	if len(partialMatches) > 0 {
-		content = fmt.Sprintf("%s**%d partial matches:**\n", content, len(partialMatches))
+		items = append(items, fmt.Sprintf("**%d partial matches:**", len(partialMatches)))
 		for _, c := range partialMatches {
-			content = fmt.Sprintf("%s    %s\n", content, c)
+			items = append(items, "    "+c)
 		}
 	}
-	if _, err := s.InteractionResponseEdit(s.State.User.ID, i.Interaction, &discordgo.WebhookEdit{
-		Content: content,
+	editPages(s, i.Interaction, header, items)
+}
+
+// setSubmitter records who submitted a proposal and where from.
+func setSubmitter(s *discordgo.Session, i *discordgo.InteractionCreate, p *proposal) {
+	user := interactionUser(i)
+	p.SubmitterID = user.ID
+	p.Submitter = user.Username
+	p.GuildID = i.GuildID
+	p.Guild = "DM"
+	if guild, err := s.Guild(i.GuildID); err == nil {
+		p.Guild = guild.Name
+	}
+	p.ChannelID = i.ChannelID
+}
+
+// createProposal opens the proposal form, the proposal itself is made by
+// submitProposal once the form is submitted.
+func createProposal(ctx context.Context, store CatalogStore, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	drafts.save(i.ID, "", formDetails(i, nil))
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: proposalForm(i.ID),
 	}); err != nil {
 		logInteractionError(s, i.Interaction, err)
-		return
 	}
 }
 
-func createProposal(ctx context.Context, client *storage.Client, s *discordgo.Session, i *discordgo.InteractionCreate) {
-	var name *discordgo.ApplicationCommandInteractionDataOption
-	var ingredients *discordgo.ApplicationCommandInteractionDataOption
-	var instructions *discordgo.ApplicationCommandInteractionDataOption
-	var garnish *discordgo.ApplicationCommandInteractionDataOption
-	for _, opt := range i.ApplicationCommandData().Options[0].Options {
-		switch opt.Name {
-		case "name":
-			name = opt
-		case "ingredients":
-			ingredients = opt
-		case "instructions":
-			instructions = opt
-		case "garnish":
-			garnish = opt
-		}
-	}
-
-	g := "None"
-	if garnish != nil {
-		g = garnish.StringValue()
-	}
-	sp := &spec{
-		Name:         name.StringValue(),
-		Ingredients:  []variation{strings.Split(ingredients.StringValue(), ",")},
-		Instructions: strings.Split(instructions.StringValue(), ","),
-		Garnish:      g,
+func submitProposal(ctx context.Context, store CatalogStore, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ModalSubmitData()
+	_, details := drafts.take(strings.TrimPrefix(data.CustomID, proposalFormID+":"))
+	sp := parseProposalForm(data, details)
+	if sp.Name == "" || len(sp.Ingredients) == 0 {
+		respond(s, i.Interaction, "A proposal needs a name and at least one ingredient", nil, true)
+		return
 	}
 
-	cocktails, err := listCocktails(ctx, client)
+	cocktails, err := listCocktails(ctx, store)
 	if err != nil {
 		logInteractionError(s, i.Interaction, err)
 		return
 	}
 
-	for _, cocktail := range cocktails {
-		if normalizeName(cocktail) == normalizeName(name.StringValue()) {
-			respond(s, i.Interaction, fmt.Sprintf("%s already exists, maybe try adding a variation?", cocktail), nil, true)
+	aliases := catalog.aliasNames()
+	for _, name := range append([]string{sp.Name}, sp.Aliases...) {
+		if cocktail := duplicateOf(name, cocktails, aliases); cocktail != "" {
+			respond(s, i.Interaction, fmt.Sprintf("%s already exists as %s, maybe try adding a variation?", name, cocktail), nil, true)
 			return
 		}
 	}
 
+	p := newProposal(proposalCreate, normalizeName(sp.Name), sp)
+	setSubmitter(s, i, p)
+	replaced, err := waitingCreates.add(ctx, store, p)
+	if err == errAlreadyPending {
+		respond(s, i.Interaction, fmt.Sprintf("Someone else's proposal for %s is already waiting on approval", sp.Name), nil, true)
+		return
+	}
+	if err != nil {
+		logInteractionError(s, i.Interaction, err)
+		return
+	}
+	if replaced != nil {
+		closeReview(s, replaced)
+	}
+
 	content := fmt.Sprintf("Spec waiting on approval, you can edit by running create again:\n%s", sp)
 	if !respond(s, i.Interaction, content, nil, true) {
 		return
 	}
-	waitingCreates.add(normalizeName(name.StringValue()), sp)
 
-	// DM Cowman
-	var user *discordgo.User
-	if i.Member != nil {
-		user = i.Member.User
-	} else {
-		user = i.User
-	}
-	guildName := "DM"
-	guild, err := s.Guild(i.GuildID)
-	if err == nil {
-		guildName = guild.Name
-	}
-	content = fmt.Sprintf("Spec submitted by %q in %q:\n%s", user.Username, guildName, sp)
-	dm(s, cowman, content)
+	content = fmt.Sprintf("Spec submitted by %q in %q:\n%s", p.Submitter, p.Guild, sp)
+	notifyApprovers(ctx, store, s, p, content)
 }
 
-func createVariation(ctx context.Context, client *storage.Client, s *discordgo.Session, i *discordgo.InteractionCreate) {
+func createVariation(ctx context.Context, store CatalogStore, s *discordgo.Session, i *discordgo.InteractionCreate) {
 	var name *discordgo.ApplicationCommandInteractionDataOption
 	var ingredients *discordgo.ApplicationCommandInteractionDataOption
 	for _, opt := range i.ApplicationCommandData().Options[0].Options {
@@ -247,113 +210,176 @@ func createVariation(ctx context.Context, client *storage.Client, s *discordgo.S
 		}
 	}
 
-	cocktails, err := listCocktails(ctx, client)
+	cocktails, err := listCocktails(ctx, store)
 	if err != nil {
 		logInteractionError(s, i.Interaction, err)
 		return
 	}
 
-	var found string
-	for _, cocktail := range cocktails {
-		if normalizeName(cocktail) == normalizeName(name.StringValue()) {
-			found = cocktail
-		}
-	}
+	found, suggestions := findCocktail(name.StringValue(), cocktails, catalog.aliasNames())
 	if found == "" {
-		respond(s, i.Interaction, fmt.Sprintf("%s not found, can't propose variation", name.StringValue()), nil, true)
+		respond(s, i.Interaction, fmt.Sprintf("%s not found, can't propose variation. %s", name.StringValue(), didYouMean(name.StringValue(), suggestions)), nil, true)
+		return
 	}
 
 	sp := &spec{
 		Name:        found,
-		Ingredients: []variation{strings.Split(ingredients.StringValue(), ",")},
+		Ingredients: []variation{parseVariation(strings.Split(ingredients.StringValue(), ","))},
+	}
+
+	p := newProposal(proposalVariation, normalizeName(found), sp)
+	setSubmitter(s, i, p)
+	replaced, err := waitingVariations.add(ctx, store, p)
+	if err == errAlreadyPending {
+		respond(s, i.Interaction, fmt.Sprintf("Someone else's proposal for %s is already waiting on approval", found), nil, true)
+		return
+	}
+	if err != nil {
+		logInteractionError(s, i.Interaction, err)
+		return
+	}
+	if replaced != nil {
+		closeReview(s, replaced)
 	}
 
 	content := fmt.Sprintf("Variation waiting on approval, you can edit by running 'create-variation' again:\n%s", sp)
 	if !respond(s, i.Interaction, content, nil, true) {
 		return
 	}
-	waitingVariations.add(normalizeName(name.StringValue()), sp)
 
-	// DM Cowman
-	var user *discordgo.User
-	if i.Member != nil {
-		user = i.Member.User
-	} else {
-		user = i.User
-	}
-	guildName := "DM"
-	guild, err := s.Guild(i.GuildID)
-	if err == nil {
-		guildName = guild.Name
-	}
-	content = fmt.Sprintf("Variation submitted by %q in %q:\n%s", user.Username, guildName, sp)
-	dm(s, cowman, content)
+	content = fmt.Sprintf("Variation submitted by %q in %q:\n%s", p.Submitter, p.Guild, sp)
+	notifyApprovers(ctx, store, s, p, content)
 }
 
-func approveProposal(ctx context.Context, client *storage.Client, s *discordgo.Session, i *discordgo.InteractionCreate) {
-	var user *discordgo.User
-	if i.Member != nil {
-		user = i.Member.User
-	} else {
-		user = i.User
+// editProposal opens the edit form pre-filled with the current spec, the
+// proposal itself is made by submitEdit once the form is submitted.
+func editProposal(ctx context.Context, store CatalogStore, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	name := subcommandOptions(i)["name"].StringValue()
+	cocktails, err := listCocktails(ctx, store)
+	if err != nil {
+		logInteractionError(s, i.Interaction, err)
+		return
 	}
-	if user.ID != cowman {
-		respond(s, i.Interaction, "You're not my boss!", nil, true)
+	found, suggestions := findCocktail(name, cocktails, catalog.aliasNames())
+	if found == "" {
+		respond(s, i.Interaction, fmt.Sprintf("%s not found, can't propose an edit. %s", name, didYouMean(name, suggestions)), nil, true)
 		return
 	}
 
-	name := i.ApplicationCommandData().Options[0].Options[0].StringValue()
-	sp, ok := waitingCreates.get(normalizeName(name))
-	if !ok {
-		respond(s, i.Interaction, fmt.Sprintf("%q not found", name), nil, true)
+	cur, err := getSpec(ctx, store, found)
+	if err != nil {
+		logInteractionError(s, i.Interaction, err)
 		return
 	}
+	drafts.save(i.ID, found, formDetails(i, cur))
 	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Flags: 1 << 6,
-		},
+		Type: discordgo.InteractionResponseModal,
+		Data: editForm(i.ID, cur),
 	}); err != nil {
 		logInteractionError(s, i.Interaction, err)
-		return
 	}
+}
 
-	data, err := json.Marshal(sp)
+func submitEdit(ctx context.Context, store CatalogStore, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ModalSubmitData()
+	// The draft says which cocktail is being edited, without one, say after a
+	// restart, there's no telling.
+	cocktail, details := drafts.take(strings.TrimPrefix(data.CustomID, editFormID+":"))
+	if details == nil {
+		respond(s, i.Interaction, "This form has expired, run /proposals edit again", nil, true)
+		return
+	}
+	cur, err := getSpec(ctx, store, cocktail)
 	if err != nil {
 		logInteractionError(s, i.Interaction, err)
 		return
 	}
-	if err := createCocktail(ctx, client, name, data); err != nil {
-		logInteractionError(s, i.Interaction, err)
+	sp := parseProposalForm(data, details)
+	if sp.Name == "" || len(sp.Ingredients) == 0 {
+		respond(s, i.Interaction, "A spec needs a name and at least one ingredient", nil, true)
 		return
 	}
-	if _, err := s.InteractionResponseEdit(s.State.User.ID, i.Interaction, &discordgo.WebhookEdit{
-		Content: fmt.Sprintf("%q approved and uploaded.", name),
-	}); err != nil {
+
+	cocktails, err := listCocktails(ctx, store)
+	if err != nil {
 		logInteractionError(s, i.Interaction, err)
 		return
 	}
-	waitingCreates.remove(normalizeName(name))
-}
+	aliases := catalog.aliasNames()
+	for _, name := range append([]string{sp.Name}, sp.Aliases...) {
+		if other := duplicateOf(name, cocktails, aliases); other != "" && other != cocktail {
+			respond(s, i.Interaction, fmt.Sprintf("%s is already used by %s", name, other), nil, true)
+			return
+		}
+	}
+
+	diff := specDiff(cur, sp)
+	if diff == noChanges {
+		respond(s, i.Interaction, fmt.Sprintf("No changes to %s", cur.Name), nil, true)
+		return
+	}
 
-func approveVariation(ctx context.Context, client *storage.Client, s *discordgo.Session, i *discordgo.InteractionCreate) {
-	var user *discordgo.User
-	if i.Member != nil {
-		user = i.Member.User
-	} else {
-		user = i.User
+	p := newProposal(proposalEdit, normalizeName(cocktail), sp)
+	p.Target = cocktail
+	setSubmitter(s, i, p)
+	replaced, err := waitingEdits.add(ctx, store, p)
+	if err == errAlreadyPending {
+		respond(s, i.Interaction, fmt.Sprintf("Someone else's proposal for %s is already waiting on approval", cur.Name), nil, true)
+		return
+	}
+	if err != nil {
+		logInteractionError(s, i.Interaction, err)
+		return
 	}
-	if user.ID != cowman {
-		respond(s, i.Interaction, "You're not my boss!", nil, true)
+	if replaced != nil {
+		closeReview(s, replaced)
+	}
+
+	content := fmt.Sprintf("Edit waiting on approval, you can change it by running 'edit' again:\n```diff\n%s\n```", truncate(diff, maxDiffLength))
+	if !respond(s, i.Interaction, content, nil, true) {
 		return
 	}
 
-	name := i.ApplicationCommandData().Options[0].Options[0].StringValue()
-	v, ok := waitingVariations.get(normalizeName(name))
+	content = fmt.Sprintf("Edit to %q submitted by %q in %q:\n```diff\n%s\n```", cur.Name, p.Submitter, p.Guild, truncate(diff, maxDiffLength))
+	notifyApprovers(ctx, store, s, p, content)
+}
+
+func approveProposal(ctx context.Context, store CatalogStore, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	decideCommand(ctx, store, s, i, &waitingCreates, statusApproved)
+}
+
+func approveVariation(ctx context.Context, store CatalogStore, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	decideCommand(ctx, store, s, i, &waitingVariations, statusApproved)
+}
+
+func denyProposal(ctx context.Context, store CatalogStore, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	decideCommand(ctx, store, s, i, &waitingCreates, statusDenied)
+}
+
+func denyVariation(ctx context.Context, store CatalogStore, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	decideCommand(ctx, store, s, i, &waitingVariations, statusDenied)
+}
+
+// decideCommand is the shared implementation of the approve and deny
+// subcommands, which take the name of the pending proposal.
+func decideCommand(ctx context.Context, store CatalogStore, s *discordgo.Session, i *discordgo.InteractionCreate, a *waitingApproval, status string) {
+	user := interactionUser(i)
+	opts := subcommandOptions(i)
+	name := opts["name"].StringValue()
+	var reason string
+	if opt, ok := opts["reason"]; ok {
+		reason = opt.StringValue()
+	}
+	p, ok := a.get(normalizeName(name))
 	if !ok {
 		respond(s, i.Interaction, fmt.Sprintf("%q not found", name), nil, true)
 		return
 	}
+	// Being an approver here isn't enough, the proposal may have come from
+	// another guild.
+	if !checkGuildApprover(ctx, store, s, i, p.GuildID) {
+		return
+	}
 	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
@@ -364,134 +390,217 @@ func approveVariation(ctx context.Context, client *storage.Client, s *discordgo.
 		return
 	}
 
-	cur, err := getSpec(ctx, client, v.Name)
-	if err != nil {
+	published, err := decide(ctx, store, s, p, status, user.ID, reason)
+	if err != nil && err != errAlreadyDecided {
 		logInteractionError(s, i.Interaction, err)
 		return
 	}
-
-	// We just take the ingredients from the proposed variation.
-	cur.Ingredients = append(cur.Ingredients, v.Ingredients...)
-
-	data, err := json.Marshal(cur)
-	if err != nil {
-		logInteractionError(s, i.Interaction, err)
-		return
+	content := fmt.Sprintf("%q denied", name)
+	switch {
+	case err == errAlreadyDecided:
+		content = fmt.Sprintf("%q was already decided or replaced", name)
+	case status == statusApproved:
+		content = fmt.Sprintf("%q approved and uploaded.", published)
 	}
-	if err := createCocktail(ctx, client, cur.Name, data); err != nil {
-		logInteractionError(s, i.Interaction, err)
-		return
-	}
-	if _, err := s.InteractionResponseEdit(s.State.User.ID, i.Interaction, &discordgo.WebhookEdit{
-		Content: fmt.Sprintf("%q approved and updated.", cur.Name),
+	if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content: &content,
 	}); err != nil {
 		logInteractionError(s, i.Interaction, err)
 		return
 	}
-	waitingCreates.remove(normalizeName(name))
 }
 
-func denyProposal(ctx context.Context, client *storage.Client, s *discordgo.Session, i *discordgo.InteractionCreate) {
-	var user *discordgo.User
-	if i.Member != nil {
-		user = i.Member.User
-	} else {
-		user = i.User
-	}
-	if user.ID != cowman {
-		respond(s, i.Interaction, "You're not my boss!", nil, true)
-		return
-	}
-
-	name := i.ApplicationCommandData().Options[0].Options[0].StringValue()
-	_, ok := waitingCreates.get(normalizeName(name))
-	if !ok {
-		respond(s, i.Interaction, fmt.Sprintf("%q not found", name), nil, true)
-		return
+func listProposals(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	waitingList := waitingCreates.list()
+	var items []string
+	for _, p := range waitingList {
+		items = append(items, p.Spec.String()+"\n")
 	}
-	waitingCreates.remove(normalizeName(name))
-	respond(s, i.Interaction, fmt.Sprintf("%q denied", name), nil, true)
+	respondPages(s, i.Interaction, fmt.Sprintf("%d proposals pending\n\n", len(waitingList)), items, true)
 }
 
-func denyVariation(ctx context.Context, client *storage.Client, s *discordgo.Session, i *discordgo.InteractionCreate) {
-	var user *discordgo.User
-	if i.Member != nil {
-		user = i.Member.User
-	} else {
-		user = i.User
-	}
-	if user.ID != cowman {
-		respond(s, i.Interaction, "You're not my boss!", nil, true)
-		return
-	}
+func approveEdit(ctx context.Context, store CatalogStore, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	decideCommand(ctx, store, s, i, &waitingEdits, statusApproved)
+}
 
-	name := i.ApplicationCommandData().Options[0].Options[0].StringValue()
-	_, ok := waitingVariations.get(normalizeName(name))
-	if !ok {
-		respond(s, i.Interaction, fmt.Sprintf("%q not found", name), nil, true)
-		return
-	}
-	waitingVariations.remove(normalizeName(name))
-	respond(s, i.Interaction, fmt.Sprintf("%q denied", name), nil, true)
+func denyEdit(ctx context.Context, store CatalogStore, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	decideCommand(ctx, store, s, i, &waitingEdits, statusDenied)
 }
 
-func listProposals(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	waitingList := waitingCreates.list()
-	content := fmt.Sprintf("%d proposals pending\n\n", len(waitingList))
+func listEdits(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	waitingList := waitingEdits.list()
+	var items []string
 	for _, p := range waitingList {
-		content = fmt.Sprintf("%s%s\n\n", content, p)
+		diff := noChanges
+		if cur, ok := catalog.spec(p.Target); ok {
+			diff = specDiff(cur, p.Spec)
+		}
+		items = append(items, fmt.Sprintf("**%s**\n```diff\n%s\n```", p.Target, truncate(diff, maxDiffLength)))
 	}
-
-	respond(s, i.Interaction, content, nil, true)
+	respondPages(s, i.Interaction, fmt.Sprintf("%d edits pending\n\n", len(waitingList)), items, true)
 }
 
 func listVariations(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	waitingList := waitingVariations.list()
-	content := fmt.Sprintf("%d variations pending\n\n", len(waitingList))
+	var items []string
 	for _, p := range waitingList {
-		content = fmt.Sprintf("%s%s\n\n", content, p)
+		items = append(items, p.Spec.String()+"\n")
+	}
+	respondPages(s, i.Interaction, fmt.Sprintf("%d variations pending\n\n", len(waitingList)), items, true)
+}
+
+func baseHandler(ctx context.Context, store CatalogStore, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	switch i.Type {
+	case discordgo.InteractionApplicationCommand:
+		commandHandler(ctx, store, s, i)
+	case discordgo.InteractionApplicationCommandAutocomplete:
+		autocomplete(ctx, store, s, i)
+	case discordgo.InteractionMessageComponent:
+		componentHandler(ctx, store, s, i)
+	case discordgo.InteractionModalSubmit:
+		modalHandler(ctx, store, s, i)
 	}
+}
 
-	respond(s, i.Interaction, content, nil, true)
+// modalHandler routes submitted forms by their custom ID.
+func modalHandler(ctx context.Context, store CatalogStore, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	switch strings.SplitN(i.ModalSubmitData().CustomID, ":", 2)[0] {
+	case proposalFormID:
+		submitProposal(ctx, store, s, i)
+	case editFormID:
+		submitEdit(ctx, store, s, i)
+	}
+}
+
+// componentHandler routes button presses by the first part of their custom
+// ID, which is made up of colon separated parts.
+func componentHandler(ctx context.Context, store CatalogStore, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	parts := strings.Split(i.MessageComponentData().CustomID, ":")
+	switch parts[0] {
+	case pageButtonID:
+		if len(parts) == 3 {
+			pageButton(s, i, parts[1], parts[2])
+		}
+	case reviewButtonID:
+		if len(parts) == 3 {
+			reviewButton(ctx, store, s, i, parts[1], parts[2])
+		}
+	}
 }
 
-func baseHandler(ctx context.Context, client *storage.Client, s *discordgo.Session, i *discordgo.InteractionCreate) {
+func commandHandler(ctx context.Context, store CatalogStore, s *discordgo.Session, i *discordgo.InteractionCreate) {
 	switch i.ApplicationCommandData().Name {
 	case "cocktail":
 		switch i.ApplicationCommandData().Options[0].Name {
 		case "random":
-			random(ctx, client, s, i)
+			random(ctx, store, s, i)
 		case "search":
-			search(ctx, client, s, i)
+			search(ctx, store, s, i)
 		case "search-ingredients":
-			searchIngredients(ctx, client, s, i)
+			searchIngredients(ctx, store, s, i)
+		case "list":
+			list(ctx, store, s, i)
+		case "batch":
+			batch(ctx, store, s, i)
+		case "units":
+			setUnits(ctx, store, s, i)
+		case "strength":
+			strengthFilter(ctx, store, s, i)
+		case "makeable":
+			makeable(ctx, store, s, i)
+		case "history":
+			history(ctx, store, s, i)
+		case "tags":
+			listTags(ctx, store, s, i)
+		case "filter":
+			filter(ctx, store, s, i)
+		case "by-creator":
+			byCreator(ctx, store, s, i)
+		case "by-source":
+			bySource(ctx, store, s, i)
+		case "rate":
+			rate(ctx, store, s, i)
+		case "top":
+			top(ctx, store, s, i)
+		case "note":
+			addNote(ctx, store, s, i)
+		case "notes":
+			listTastingNotes(ctx, store, s, i)
+		}
+	case "bar":
+		switch i.ApplicationCommandData().Options[0].Name {
+		case "add":
+			barAdd(ctx, store, s, i)
+		case "remove":
+			barRemove(ctx, store, s, i)
+		case "list":
+			barList(ctx, store, s, i)
+		}
+	case "fav":
+		switch i.ApplicationCommandData().Options[0].Name {
+		case "add":
+			favAdd(ctx, store, s, i)
+		case "remove":
+			favRemove(ctx, store, s, i)
+		case "list":
+			favList(ctx, store, s, i)
+		}
+	case "collection":
+		switch i.ApplicationCommandData().Options[0].Name {
+		case "create":
+			collectionCreate(ctx, store, s, i)
+		case "delete":
+			collectionDelete(ctx, store, s, i)
+		case "add":
+			collectionAdd(ctx, store, s, i)
+		case "remove":
+			collectionRemove(ctx, store, s, i)
 		case "list":
-			list(ctx, client, s, i)
+			collectionList(ctx, store, s, i)
 		}
 	case "proposals":
 		switch i.ApplicationCommandData().Options[0].Name {
 		case "create":
-			createProposal(ctx, client, s, i)
+			createProposal(ctx, store, s, i)
 		case "create-variation":
-			createVariation(ctx, client, s, i)
+			createVariation(ctx, store, s, i)
 		case "list":
 			listProposals(s, i)
 		case "list-variations":
 			listVariations(s, i)
 		case "deny":
-			denyProposal(ctx, client, s, i)
+			denyProposal(ctx, store, s, i)
 		case "deny-variation":
-			denyVariation(ctx, client, s, i)
+			denyVariation(ctx, store, s, i)
 		case "approve":
-			approveProposal(ctx, client, s, i)
+			approveProposal(ctx, store, s, i)
 		case "approve-variation":
-			approveVariation(ctx, client, s, i)
+			approveVariation(ctx, store, s, i)
+		case "edit":
+			editProposal(ctx, store, s, i)
+		case "approve-edit":
+			approveEdit(ctx, store, s, i)
+		case "deny-edit":
+			denyEdit(ctx, store, s, i)
+		case "list-edits":
+			listEdits(s, i)
+		}
+	case "admin":
+		switch i.ApplicationCommandData().Options[0].Name {
+		case "approvers":
+			adminApprovers(ctx, store, s, i)
+		case "notifications":
+			adminNotifications(ctx, store, s, i)
+		case "rollback":
+			adminRollback(ctx, store, s, i)
+		case "delete-note":
+			adminDeleteNote(ctx, store, s, i)
 		}
 	}
 }
 
 // messageCreate is required because commands don't support files yet.
-func messageCreate(ctx context.Context, client *storage.Client, s *discordgo.Session, m *discordgo.MessageCreate) {
+func messageCreate(ctx context.Context, store CatalogStore, s *discordgo.Session, m *discordgo.MessageCreate) {
 	if m.Author.ID == s.State.User.ID {
 		return
 	}
@@ -501,48 +610,44 @@ func messageCreate(ctx context.Context, client *storage.Client, s *discordgo.Ses
 		return
 	}
 
-	if m.Author.ID != cowman {
+	var roles []string
+	if m.Member != nil {
+		roles = m.Member.Roles
+	}
+	if ok, err := isApprover(ctx, store, m.GuildID, m.Author.ID, roles); err != nil || !ok {
+		if err != nil {
+			log.Print(err)
+		}
 		return
 	}
 
 	name := strings.TrimPrefix(m.Message.Content, prefix)
-	cocktails, err := listCocktails(ctx, client)
+	cocktails, err := listCocktails(ctx, store)
 	if err != nil {
 		fmt.Println(err)
 		return
 	}
-	var found string
-	for _, cocktail := range cocktails {
-		if normalizeName(strings.TrimSuffix(cocktail, "/")) == normalizeName(name) {
-			found = cocktail
-			break
-		}
-	}
+	found, suggestions := findCocktail(name, cocktails, catalog.aliasNames())
 	if found == "" {
-		if _, err := s.ChannelMessageSend(m.ChannelID, "Cocktail not found: "+name); err != nil {
+		if _, err := s.ChannelMessageSend(m.ChannelID, "Cocktail not found: "+name+"\n"+didYouMean(name, suggestions)); err != nil {
 			log.Print(err)
 		}
 		return
 	}
 
 	for _, attach := range m.Attachments {
-		writer := client.Bucket(*bucket).Object(path.Join(found, "pictures", attach.Filename)).NewWriter(ctx)
 		resp, err := http.Get(attach.URL)
 		if err != nil {
 			fmt.Println(err)
 			continue
 		}
 		defer resp.Body.Close()
-		if _, err := io.Copy(writer, resp.Body); err != nil {
-			fmt.Println(err)
-			continue
-		}
-		if err := writer.Close(); err != nil {
+		if err := store.WritePicture(ctx, found, attach.Filename, resp.Body); err != nil {
 			fmt.Println(err)
 			continue
 		}
 	}
-	if _, err := s.ChannelMessageSend(m.ChannelID, "Attachments uploaded for "+name); err != nil {
+	if _, err := s.ChannelMessageSend(m.ChannelID, "Attachments uploaded for "+found); err != nil {
 		log.Print(err)
 	}
 }
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// versionFormat names history entries so they sort by when they were saved.
+const versionFormat = "20060102T150405.000000000Z"
+
+// specVersion is a copy of a spec as it was written to the catalog, kept at
+// <name>/history/<timestamp>.json. Versions are numbered from 1 in the order
+// they were saved.
+type specVersion struct {
+	Saved      time.Time
+	AuthorID   string `json:",omitempty"`
+	Author     string `json:",omitempty"`
+	ApproverID string `json:",omitempty"`
+	// GuildID is where the version was approved, its approvers may roll the
+	// spec back.
+	GuildID string `json:",omitempty"`
+	// Note says how the version came about when it wasn't a normal
+	// proposal, such as a rollback.
+	Note string `json:",omitempty"`
+	Spec *spec
+}
+
+func historyPrefix(cocktail string) string {
+	return path.Join(cocktail, "history")
+}
+
+// listVersions returns the history keys of a cocktail, oldest first.
+func listVersions(ctx context.Context, store CatalogStore, cocktail string) ([]string, error) {
+	keys, err := store.ListObjects(ctx, historyPrefix(cocktail))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func readVersion(ctx context.Context, store CatalogStore, key string) (*specVersion, error) {
+	data, err := store.ReadObject(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	var v specVersion
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("error parsing version %q: %v", key, err)
+	}
+	return &v, nil
+}
+
+func writeVersion(ctx context.Context, store CatalogStore, cocktail string, v *specVersion) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return store.WriteObject(ctx, path.Join(historyPrefix(cocktail), v.Saved.Format(versionFormat)+".json"), data)
+}
+
+// saveSpec writes a new version of a cocktail's spec, keeping a copy in its
+// history. Specs written before history was kept get their current spec saved
+// as the first version so it isn't lost.
+func saveSpec(ctx context.Context, store CatalogStore, cocktail string, v *specVersion) error {
+	keys, err := listVersions(ctx, store, cocktail)
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		data, err := store.ReadObject(ctx, path.Join(cocktail, "spec"))
+		switch {
+		case err == errNotFound:
+		case err != nil:
+			return err
+		default:
+			old, err := parseSpec(data)
+			if err != nil {
+				return err
+			}
+			first := &specVersion{Saved: time.Now().UTC(), Note: "saved before history was kept", Spec: old}
+			if err := writeVersion(ctx, store, cocktail, first); err != nil {
+				return err
+			}
+		}
+	}
+
+	v.Saved = time.Now().UTC()
+	if err := writeVersion(ctx, store, cocktail, v); err != nil {
+		return err
+	}
+	data, err := json.Marshal(v.Spec)
+	if err != nil {
+		return err
+	}
+	return createCocktail(ctx, store, cocktail, data)
+}
+
+// describe is the one line summary of a version shown by /cocktail history.
+func (v *specVersion) describe(n int) string {
+	line := fmt.Sprintf("**%d.** %s", n, v.Saved.Format("2006-01-02 15:04 MST"))
+	if v.AuthorID != "" {
+		line += fmt.Sprintf(", by <@%s>", v.AuthorID)
+	}
+	if v.ApproverID != "" {
+		line += fmt.Sprintf(", approved by <@%s>", v.ApproverID)
+	}
+	if v.Note != "" {
+		line += ", " + v.Note
+	}
+	return line
+}
+
+func history(ctx context.Context, store CatalogStore, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	name := subcommandOptions(i)["name"].StringValue()
+	cocktails, err := listCocktails(ctx, store)
+	if err != nil {
+		logInteractionError(s, i.Interaction, err)
+		return
+	}
+	found, suggestions := findCocktail(name, cocktails, catalog.aliasNames())
+	if found == "" {
+		respond(s, i.Interaction, didYouMean(name, suggestions), nil, true)
+		return
+	}
+
+	keys, err := listVersions(ctx, store, found)
+	if err != nil {
+		logInteractionError(s, i.Interaction, err)
+		return
+	}
+	if len(keys) == 0 {
+		respond(s, i.Interaction, fmt.Sprintf("%s hasn't changed since history started being kept", found), nil, true)
+		return
+	}
+
+	// Newest first, which is what people usually want to look at.
+	var items []string
+	for n := len(keys) - 1; n >= 0; n-- {
+		v, err := readVersion(ctx, store, keys[n])
+		if err != nil {
+			logInteractionError(s, i.Interaction, err)
+			return
+		}
+		items = append(items, v.describe(n+1))
+	}
+	respondPages(s, i.Interaction, fmt.Sprintf("%d versions of %s, roll back with /admin rollback:\n", len(keys), found), items, true)
+}
+
+// adminRollback restores an earlier version of a spec. The catalog is shared
+// between guilds, so only approvers of the guild the current version came
+// from and the bot owner may do it.
+func adminRollback(ctx context.Context, store CatalogStore, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	opts := subcommandOptions(i)
+	name := opts["name"].StringValue()
+	n := int(opts["version"].IntValue())
+
+	cocktails, err := listCocktails(ctx, store)
+	if err != nil {
+		logInteractionError(s, i.Interaction, err)
+		return
+	}
+	found, suggestions := findCocktail(name, cocktails, catalog.aliasNames())
+	if found == "" {
+		respond(s, i.Interaction, didYouMean(name, suggestions), nil, true)
+		return
+	}
+	keys, err := listVersions(ctx, store, found)
+	if err != nil {
+		logInteractionError(s, i.Interaction, err)
+		return
+	}
+	if n < 1 || n > len(keys) {
+		respond(s, i.Interaction, fmt.Sprintf("%s has versions 1 to %d, see /cocktail history", found, len(keys)), nil, true)
+		return
+	}
+	cur, err := readVersion(ctx, store, keys[len(keys)-1])
+	if err != nil {
+		logInteractionError(s, i.Interaction, err)
+		return
+	}
+	if !checkGuildApprover(ctx, store, s, i, cur.GuildID) {
+		return
+	}
+	old, err := readVersion(ctx, store, keys[n-1])
+	if err != nil {
+		logInteractionError(s, i.Interaction, err)
+		return
+	}
+
+	// Rolling back is a new version, so it can be undone too.
+	user := interactionUser(i)
+	v := &specVersion{
+		ApproverID: user.ID,
+		GuildID:    cur.GuildID,
+		Note:       fmt.Sprintf("rollback to version %d", n),
+		Spec:       old.Spec,
+	}
+	if err := saveSpec(ctx, store, found, v); err != nil {
+		logInteractionError(s, i.Interaction, err)
+		return
+	}
+	catalog.update(found, old.Spec)
+	// The whole spec can be longer than a message, it's a search away.
+	respond(s, i.Interaction, fmt.Sprintf("Rolled %s back to version %d, see it with /cocktail search", found, n), nil, true)
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// variationRef identifies a single variation of a cocktail.
+type variationRef struct {
+	cocktail  string
+	variation int
+}
+
+// ingredientRef identifies a single ingredient in a variation.
+type ingredientRef struct {
+	variationRef
+	ingredient int
+}
+
+// catalogIndex is an in memory copy of every spec in the catalog along with
+// an inverted index from ingredient tokens to the variations using them, so
+// searches don't need to read every spec from the store.
+type catalogIndex struct {
+	sync.RWMutex
+	specs  map[string]*spec
+	tokens map[string]map[ingredientRef]bool
+	// aliases maps each alias to the cocktail it names.
+	aliases map[string]string
+
+	// rebuilding serializes rebuilds. While one runs, updates are also kept
+	// in updated so they can be applied to the fresh index before it is
+	// swapped in, otherwise a spec published mid rebuild would be lost.
+	rebuilding sync.Mutex
+	updated    map[string]*spec
+}
+
+func newCatalogIndex() *catalogIndex {
+	return &catalogIndex{
+		specs:   map[string]*spec{},
+		tokens:  map[string]map[ingredientRef]bool{},
+		aliases: map[string]string{},
+	}
+}
+
+// tokenize splits text into lower case words for indexing.
+func tokenize(s string) []string {
+	return strings.Fields(nonWord.ReplaceAllString(strings.ToLower(s), " "))
+}
+
+// rebuild replaces the index with a fresh copy of the catalog from the store.
+// Specs that fail to load are logged and skipped.
+func (c *catalogIndex) rebuild(ctx context.Context, store CatalogStore) error {
+	c.rebuilding.Lock()
+	defer c.rebuilding.Unlock()
+	c.Lock()
+	c.updated = map[string]*spec{}
+	c.Unlock()
+	defer func() {
+		c.Lock()
+		c.updated = nil
+		c.Unlock()
+	}()
+
+	cocktails, err := listCocktails(ctx, store)
+	if err != nil {
+		return err
+	}
+	fresh := newCatalogIndex()
+	for _, cocktail := range cocktails {
+		sp, err := getSpec(ctx, store, cocktail)
+		if err != nil {
+			log.Printf("Error indexing %q: %v", cocktail, err)
+			continue
+		}
+		fresh.add(cocktail, sp)
+	}
+
+	c.Lock()
+	defer c.Unlock()
+	for cocktail, sp := range c.updated {
+		fresh.remove(cocktail)
+		fresh.add(cocktail, sp)
+	}
+	c.specs = fresh.specs
+	c.tokens = fresh.tokens
+	c.aliases = fresh.aliases
+	return nil
+}
+
+// refresh rebuilds the index every interval until ctx is done.
+func (c *catalogIndex) refresh(ctx context.Context, store CatalogStore, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.rebuild(ctx, store); err != nil {
+				log.Printf("Error rebuilding catalog index: %v", err)
+			}
+		}
+	}
+}
+
+// add indexes a spec, the caller must hold the lock.
+func (c *catalogIndex) add(cocktail string, sp *spec) {
+	c.specs[cocktail] = sp
+	for _, alias := range sp.Aliases {
+		if alias = strings.TrimSpace(alias); alias != "" {
+			c.aliases[alias] = cocktail
+		}
+	}
+	for n, v := range sp.Ingredients {
+		for m, ing := range v {
+			ref := ingredientRef{variationRef{cocktail: cocktail, variation: n}, m}
+			for _, tok := range tokenize(ing.Raw) {
+				if c.tokens[tok] == nil {
+					c.tokens[tok] = map[ingredientRef]bool{}
+				}
+				c.tokens[tok][ref] = true
+			}
+		}
+	}
+}
+
+// remove drops a cocktail from the index, the caller must hold the lock.
+func (c *catalogIndex) remove(cocktail string) {
+	delete(c.specs, cocktail)
+	for alias, name := range c.aliases {
+		if name == cocktail {
+			delete(c.aliases, alias)
+		}
+	}
+	for tok, refs := range c.tokens {
+		for ref := range refs {
+			if ref.cocktail == cocktail {
+				delete(refs, ref)
+			}
+		}
+		if len(refs) == 0 {
+			delete(c.tokens, tok)
+		}
+	}
+}
+
+// update replaces the indexed spec for a cocktail after it is written.
+func (c *catalogIndex) update(cocktail string, sp *spec) {
+	c.Lock()
+	defer c.Unlock()
+	c.remove(cocktail)
+	c.add(cocktail, sp)
+	if c.updated != nil {
+		c.updated[cocktail] = sp
+	}
+}
+
+// names returns the sorted names of all indexed cocktails.
+func (c *catalogIndex) names() []string {
+	c.RLock()
+	defer c.RUnlock()
+	var names []string
+	for name := range c.specs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// aliasNames returns a copy of the alias to cocktail mapping.
+func (c *catalogIndex) aliasNames() map[string]string {
+	c.RLock()
+	defer c.RUnlock()
+	aliases := map[string]string{}
+	for alias, cocktail := range c.aliases {
+		aliases[alias] = cocktail
+	}
+	return aliases
+}
+
+// spec returns the indexed spec for a cocktail, it must not be modified.
+func (c *catalogIndex) spec(cocktail string) (*spec, bool) {
+	c.RLock()
+	defer c.RUnlock()
+	sp, ok := c.specs[cocktail]
+	return sp, ok
+}
+
+// lookup returns the variations with an ingredient matching query, every word
+// in query must match a word of the same ingredient. Words are matched
+// exactly if possible and as a prefix otherwise.
+func (c *catalogIndex) lookup(query string) map[variationRef]bool {
+	c.RLock()
+	defer c.RUnlock()
+	var found map[ingredientRef]bool
+	for _, want := range tokenize(query) {
+		matches := map[ingredientRef]bool{}
+		for tok, refs := range c.tokens {
+			// Exact words win, so "gin" doesn't turn up ginger beer.
+			if tok != want && (c.tokens[want] != nil || !strings.HasPrefix(tok, want)) {
+				continue
+			}
+			for ref := range refs {
+				if found == nil || found[ref] {
+					matches[ref] = true
+				}
+			}
+		}
+		found = matches
+	}
+	variations := map[variationRef]bool{}
+	for ref := range found {
+		variations[ref.variationRef] = true
+	}
+	return variations
+}
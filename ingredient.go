@@ -0,0 +1,287 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ingredient is a single entry in a variation, e.g. "1 1/2 oz gin (Tanqueray)".
+// Raw always holds the text as submitted so nothing is lost when parsing only
+// partially understands an entry.
+type ingredient struct {
+	Raw string
+	// Amount is zero when no quantity was given, e.g. "top with soda".
+	Amount float64 `json:",omitempty"`
+	// Unit is the canonical unit name from the units table, empty for
+	// counted items like "1 egg white".
+	Unit  string `json:",omitempty"`
+	Name  string `json:",omitempty"`
+	Brand string `json:",omitempty"`
+	Notes string `json:",omitempty"`
+}
+
+// unit describes a unit of measure and how many millilitres it holds, ml is
+// zero for units that aren't a fixed volume.
+type unit struct {
+	name    string
+	plural  string
+	ml      float64
+	aliases []string
+}
+
+var units = []unit{
+	{name: "oz", plural: "oz", ml: 29.5735, aliases: []string{"ounce", "ounces", "fl oz", "fl. oz", "fl.oz"}},
+	{name: "ml", plural: "ml", ml: 1, aliases: []string{"millilitre", "millilitres", "milliliter", "milliliters", "mls"}},
+	{name: "cl", plural: "cl", ml: 10, aliases: []string{"centilitre", "centilitres", "centiliter", "centiliters"}},
+	{name: "dash", plural: "dashes", ml: 0.92},
+	{name: "barspoon", plural: "barspoons", ml: 5, aliases: []string{"bar spoon", "bar spoons", "bsp"}},
+	{name: "tsp", plural: "tsp", ml: 4.93, aliases: []string{"teaspoon", "teaspoons"}},
+	{name: "tbsp", plural: "tbsp", ml: 14.79, aliases: []string{"tablespoon", "tablespoons"}},
+	{name: "drop", plural: "drops", ml: 0.05},
+	{name: "splash", plural: "splashes", ml: 7.5},
+	{name: "cup", plural: "cups", ml: 236.6},
+	{name: "part", plural: "parts"},
+	{name: "leaf", plural: "leaves"},
+	{name: "slice", plural: "slices"},
+	{name: "wedge", plural: "wedges"},
+	{name: "sprig", plural: "sprigs"},
+}
+
+func lookupUnit(name string) (unit, bool) {
+	name = strings.TrimSuffix(strings.ToLower(name), ".")
+	for _, u := range units {
+		if name == u.name || name == u.plural {
+			return u, true
+		}
+		for _, a := range u.aliases {
+			if name == a {
+				return u, true
+			}
+		}
+	}
+	return unit{}, false
+}
+
+var unicodeFractions = map[rune]float64{
+	'¼': 0.25,
+	'½': 0.5,
+	'¾': 0.75,
+	'⅓': 1.0 / 3,
+	'⅔': 2.0 / 3,
+	'⅛': 0.125,
+}
+
+// parseNumber parses a single quantity token such as "2", "0.75", "3/4", "½",
+// "1½" or a range like "2-3", which is averaged.
+func parseNumber(tok string) (float64, bool) {
+	if i := strings.Index(tok, "-"); i >= 0 {
+		l, ok1 := parseNumber(tok[:i])
+		h, ok2 := parseNumber(tok[i+1:])
+		if !ok1 || !ok2 {
+			return 0, false
+		}
+		return (l + h) / 2, true
+	}
+	if i := strings.Index(tok, "/"); i >= 0 {
+		n, err1 := strconv.ParseFloat(tok[:i], 64)
+		d, err2 := strconv.ParseFloat(tok[i+1:], 64)
+		if err1 != nil || err2 != nil || d == 0 {
+			return 0, false
+		}
+		return n / d, true
+	}
+	var frac float64
+	if r := []rune(tok); len(r) > 0 {
+		if f, ok := unicodeFractions[r[len(r)-1]]; ok {
+			frac = f
+			tok = string(r[:len(r)-1])
+			if tok == "" {
+				return frac, true
+			}
+		}
+	}
+	n, err := strconv.ParseFloat(tok, 64)
+	if err != nil {
+		return 0, false
+	}
+	return n + frac, true
+}
+
+func isFraction(tok string) bool {
+	if strings.Contains(tok, "/") {
+		return true
+	}
+	for _, r := range tok {
+		if _, ok := unicodeFractions[r]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// splitLeadingNumber splits a token like "30ml" into "30" and "ml".
+func splitLeadingNumber(tok string) (string, string) {
+	i := strings.IndexFunc(tok, func(r rune) bool {
+		_, frac := unicodeFractions[r]
+		return !unicode.IsDigit(r) && !frac && r != '.' && r != '/' && r != '-'
+	})
+	if i <= 0 {
+		return tok, ""
+	}
+	return tok[:i], tok[i:]
+}
+
+// parseIngredient converts a free text entry into an ingredient. Anything it
+// doesn't recognize ends up in Name, so parsing never fails outright.
+func parseIngredient(raw string) ingredient {
+	ing := ingredient{Raw: strings.TrimSpace(raw)}
+	s := ing.Raw
+
+	if i := strings.Index(s, ","); i >= 0 {
+		ing.Notes = strings.TrimSpace(s[i+1:])
+		s = s[:i]
+	}
+	if open := strings.Index(s, "("); open >= 0 {
+		if end := strings.Index(s[open:], ")"); end > 0 {
+			ing.Brand = strings.TrimSpace(s[open+1 : open+end])
+			s = s[:open] + s[open+end+1:]
+		}
+	}
+
+	fields := strings.Fields(s)
+	// Quantity, possibly a mixed number like "1 1/2" or glued to its unit
+	// like "30ml".
+	for len(fields) > 0 {
+		num, rest := splitLeadingNumber(fields[0])
+		// Only a fraction may follow the whole part of a mixed number.
+		if ing.Amount != 0 && !isFraction(num) {
+			break
+		}
+		if rest != "" {
+			if _, ok := lookupUnit(rest); !ok {
+				break
+			}
+		}
+		n, ok := parseNumber(num)
+		if !ok {
+			break
+		}
+		ing.Amount += n
+		if rest != "" {
+			fields[0] = rest
+			break
+		}
+		fields = fields[1:]
+	}
+	// Unit, checking two word units like "bar spoon" first.
+	if len(fields) > 1 {
+		if u, ok := lookupUnit(fields[0] + " " + fields[1]); ok {
+			ing.Unit = u.name
+			fields = fields[2:]
+		}
+	}
+	if ing.Unit == "" && len(fields) > 0 {
+		if u, ok := lookupUnit(fields[0]); ok {
+			ing.Unit = u.name
+			fields = fields[1:]
+		}
+	}
+	if ing.Unit != "" && len(fields) > 0 && strings.ToLower(fields[0]) == "of" {
+		fields = fields[1:]
+	}
+	// A bare unit like "dash of bitters" means one of them.
+	if ing.Unit != "" && ing.Amount == 0 {
+		ing.Amount = 1
+	}
+	ing.Name = strings.Join(fields, " ")
+	return ing
+}
+
+func parseVariation(entries []string) variation {
+	var v variation
+	for _, e := range entries {
+		if strings.TrimSpace(e) == "" {
+			continue
+		}
+		v = append(v, parseIngredient(e))
+	}
+	return v
+}
+
+func (ing ingredient) String() string {
+	return ing.Raw
+}
+
+// parsed reports whether a quantity was understood for this ingredient.
+func (ing ingredient) parsed() bool {
+	return ing.Amount > 0 && ing.Name != ""
+}
+
+// ml returns the volume of the ingredient in millilitres, zero if it has no
+// known volume.
+func (ing ingredient) ml() float64 {
+	u, ok := lookupUnit(ing.Unit)
+	if !ok {
+		return 0
+	}
+	return ing.Amount * u.ml
+}
+
+// scale returns a copy of the ingredient with its amount multiplied by n.
+func (ing ingredient) scale(n float64) ingredient {
+	ing.Amount *= n
+	ing.Raw = ing.describe()
+	return ing
+}
+
+// describe renders the ingredient from its parsed fields, falling back to the
+// raw text if it was never parsed.
+func (ing ingredient) describe() string {
+	if !ing.parsed() {
+		return ing.Raw
+	}
+	desc := formatAmount(ing.Amount)
+	if ing.Unit == "oz" {
+		desc = formatOz(ing.Amount)
+	}
+	if u, ok := lookupUnit(ing.Unit); ok {
+		if ing.Amount == 1 {
+			desc += " " + u.name
+		} else {
+			desc += " " + u.plural
+		}
+	}
+	desc += " " + ing.Name
+	if ing.Brand != "" {
+		desc += " (" + ing.Brand + ")"
+	}
+	if ing.Notes != "" {
+		desc += ", " + ing.Notes
+	}
+	return desc
+}
+
+// formatAmount formats a quantity with at most two decimal places.
+func formatAmount(f float64) string {
+	return strconv.FormatFloat(math.Round(f*100)/100, 'f', -1, 64)
+}
+
+// UnmarshalJSON accepts both the structured form and the plain strings that
+// older specs were stored with.
+func (ing *ingredient) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err == nil {
+		*ing = parseIngredient(raw)
+		return nil
+	}
+	type plain ingredient
+	var p plain
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	*ing = ingredient(p)
+	return nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestParseIngredient(t *testing.T) {
+	for _, tc := range []struct {
+		raw  string
+		want ingredient
+	}{
+		{"2 oz gin", ingredient{Amount: 2, Unit: "oz", Name: "gin"}},
+		{"1 1/2 oz gin (Tanqueray), chilled", ingredient{Amount: 1.5, Unit: "oz", Name: "gin", Brand: "Tanqueray", Notes: "chilled"}},
+		{"30ml lime juice", ingredient{Amount: 30, Unit: "ml", Name: "lime juice"}},
+		{"½ oz simple syrup", ingredient{Amount: 0.5, Unit: "oz", Name: "simple syrup"}},
+		{"1½ oz rum", ingredient{Amount: 1.5, Unit: "oz", Name: "rum"}},
+		{"2-3 dashes Angostura bitters", ingredient{Amount: 2.5, Unit: "dash", Name: "Angostura bitters"}},
+		{"dash of bitters", ingredient{Amount: 1, Unit: "dash", Name: "bitters"}},
+		{"2 bar spoons maraschino", ingredient{Amount: 2, Unit: "barspoon", Name: "maraschino"}},
+		{"1 fl oz gin", ingredient{Amount: 1, Unit: "oz", Name: "gin"}},
+		{"2 ounces of gin", ingredient{Amount: 2, Unit: "oz", Name: "gin"}},
+		{"1 egg white", ingredient{Amount: 1, Name: "egg white"}},
+		{"top with soda", ingredient{Name: "top with soda"}},
+		{"3/0 oz gin", ingredient{Name: "3/0 oz gin"}},
+		{"  2 oz gin  ", ingredient{Amount: 2, Unit: "oz", Name: "gin"}},
+	} {
+		want := tc.want
+		want.Raw = strings.TrimSpace(tc.raw)
+		if got := parseIngredient(tc.raw); got != want {
+			t.Errorf("parseIngredient(%q) = %+v, want %+v", tc.raw, got, want)
+		}
+	}
+}
+
+func TestParseNumber(t *testing.T) {
+	for _, tc := range []struct {
+		tok  string
+		want float64
+		ok   bool
+	}{
+		{"2", 2, true},
+		{"0.75", 0.75, true},
+		{"3/4", 0.75, true},
+		{"½", 0.5, true},
+		{"1½", 1.5, true},
+		{"2-3", 2.5, true},
+		{"1/0", 0, false},
+		{"-", 0, false},
+		{"", 0, false},
+		{"gin", 0, false},
+	} {
+		got, ok := parseNumber(tc.tok)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("parseNumber(%q) = %v, %v, want %v, %v", tc.tok, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestScaleIngredient(t *testing.T) {
+	for _, tc := range []struct {
+		raw  string
+		n    float64
+		want string
+	}{
+		{"1 1/2 oz gin", 2, "3 oz gin"},
+		{"1 dash bitters", 3, "3 dashes bitters"},
+		{"2 oz gin (Tanqueray), chilled", 0.5, "1 oz gin (Tanqueray), chilled"},
+		{"30 ml lime juice", 1.5, "45 ml lime juice"},
+		{"top with soda", 4, "top with soda"},
+	} {
+		if got := parseIngredient(tc.raw).scale(tc.n).Raw; got != tc.want {
+			t.Errorf("scale(%q, %v) = %q, want %q", tc.raw, tc.n, got, tc.want)
+		}
+	}
+}
+
+func TestUnmarshalIngredient(t *testing.T) {
+	for _, tc := range []struct {
+		data string
+		want ingredient
+	}{
+		{`"2 oz gin"`, ingredient{Raw: "2 oz gin", Amount: 2, Unit: "oz", Name: "gin"}},
+		{`{"Raw":"2 oz gin","Amount":2,"Unit":"oz","Name":"gin"}`, ingredient{Raw: "2 oz gin", Amount: 2, Unit: "oz", Name: "gin"}},
+	} {
+		var got ingredient
+		if err := json.Unmarshal([]byte(tc.data), &got); err != nil || got != tc.want {
+			t.Errorf("Unmarshal(%s) = %+v, %v, want %+v", tc.data, got, err, tc.want)
+		}
+	}
+}
@@ -1,35 +1,36 @@
 package main
 
 import (
-	"bytes"
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
-	"io"
-	"io/ioutil"
 	"log"
 	"math/rand"
 	"os"
 	"os/signal"
 	"path"
 	"strings"
-	"sync"
 	"syscall"
 	"time"
 
-	"cloud.google.com/go/storage"
 	"github.com/bwmarrin/discordgo"
-	"google.golang.org/api/iterator"
 )
 
 var (
-	token  = flag.String("token", "", "discord bot token")
-	bucket = flag.String("bucket", "", "gcs bucket to use")
-
-	cowman = "780258092042551376"
-
-	waitingCreates    = waitingApproval{pending: map[string]*spec{}}
-	waitingVariations = waitingApproval{pending: map[string]*spec{}}
+	token     = flag.String("token", "", "discord bot token")
+	storeType = flag.String("store", "gcs", "catalog store to use, one of gcs or fs")
+	bucket    = flag.String("bucket", "", "gcs bucket to use with -store=gcs")
+	dir       = flag.String("dir", "", "directory to use with -store=fs")
+	owner     = flag.String("owner", "780258092042551376", "user ID of the bot owner, who can approve in every guild")
+	refresh   = flag.Duration("index-refresh", 15*time.Minute, "how often to rebuild the catalog index from the store")
+	migrate   = flag.Bool("migrate-specs", false, "rewrite every spec in the current format and exit")
+
+	waitingCreates    = waitingApproval{kind: proposalCreate, pending: map[string]*proposal{}}
+	waitingVariations = waitingApproval{kind: proposalVariation, pending: map[string]*proposal{}}
+	waitingEdits      = waitingApproval{kind: proposalEdit, pending: map[string]*proposal{}}
+
+	catalog = newCatalogIndex()
 )
 
 var (
@@ -42,17 +43,173 @@ var (
 					Name:        "random",
 					Description: "display a random cocktail",
 					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: append([]*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionString,
+							Name:         "tag",
+							Description:  "only pick cocktails with this tag, like sour or tiki",
+							Required:     false,
+							Autocomplete: true,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "base",
+							Description: "only pick cocktails with this base spirit, like gin or rum",
+							Required:    false,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "units",
+							Description: "units to show pours in, defaults to your saved preference",
+							Required:    false,
+							Choices:     unitSystemChoices,
+						},
+						{
+							Type:         discordgo.ApplicationCommandOptionString,
+							Name:         "from",
+							Description:  "only pick from your favorites or one of your collections",
+							Required:     false,
+							Autocomplete: true,
+						},
+					}, servingOptions(true)...),
+				},
+				{
+					Name:        "filter",
+					Description: "list the cocktails by tag, base spirit, glass, method or ice",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: append([]*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionString,
+							Name:         "tag",
+							Description:  "only cocktails with this tag, like sour or tiki",
+							Required:     false,
+							Autocomplete: true,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "base",
+							Description: "only cocktails with this base spirit, like gin or rum",
+							Required:    false,
+						},
+					}, servingOptions(true)...),
+				},
+				{
+					Name:        "rate",
+					Description: "rate a cocktail from 1 to 5 stars",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionString,
+							Name:         "name",
+							Description:  "name of the cocktail to rate",
+							Required:     true,
+							Autocomplete: true,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "stars",
+							Description: "how many stars to give it",
+							Required:    true,
+							MinValue:    &minStars,
+							MaxValue:    maxStars,
+						},
+					},
+				},
+				{
+					Name:        "top",
+					Description: "list the highest rated cocktails",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionString,
+							Name:         "tag",
+							Description:  "only cocktails with this tag, like sour or tiki",
+							Required:     false,
+							Autocomplete: true,
+						},
+					},
+				},
+				{
+					Name:        "note",
+					Description: "add a tasting note to a cocktail",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionString,
+							Name:         "name",
+							Description:  "name of the cocktail",
+							Required:     true,
+							Autocomplete: true,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "text",
+							Description: "what you thought of it",
+							Required:    true,
+							MaxLength:   maxNoteLength,
+						},
+					},
+				},
+				{
+					Name:        "notes",
+					Description: "list the tasting notes on a cocktail",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionString,
+							Name:         "name",
+							Description:  "name of the cocktail",
+							Required:     true,
+							Autocomplete: true,
+						},
+					},
+				},
+				{
+					Name:        "by-creator",
+					Description: "list the cocktails created by someone",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionString,
+							Name:         "name",
+							Description:  "name of the creator",
+							Required:     true,
+							Autocomplete: true,
+						},
+					},
+				},
+				{
+					Name:        "by-source",
+					Description: "list the cocktails from a book, bar or website",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionString,
+							Name:         "source",
+							Description:  "book, bar or URL the specs come from",
+							Required:     true,
+							Autocomplete: true,
+						},
+					},
 				},
 				{
 					Name:        "search",
 					Description: "search for a cocktail by name",
 					Type:        discordgo.ApplicationCommandOptionSubCommand,
 					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionString,
+							Name:         "name",
+							Description:  "name of the cocktail to search for",
+							Required:     true,
+							Autocomplete: true,
+						},
 						{
 							Type:        discordgo.ApplicationCommandOptionString,
-							Name:        "name",
-							Description: "name of the cocktail to search for",
-							Required:    true,
+							Name:        "units",
+							Description: "units to show pours in, defaults to your saved preference",
+							Required:    false,
+							Choices:     unitSystemChoices,
 						},
 					},
 				},
@@ -61,6 +218,102 @@ var (
 					Description: "list all cocktails",
 					Type:        discordgo.ApplicationCommandOptionSubCommand,
 				},
+				{
+					Name:        "units",
+					Description: "set the units specs are shown in",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "system",
+							Description: "units to show pours in",
+							Required:    true,
+							Choices:     unitSystemChoices,
+						},
+					},
+				},
+				{
+					Name:        "strength",
+					Description: "find cocktails by estimated ABV",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "under",
+							Description: "only cocktails under this ABV percentage",
+							Required:    false,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "over",
+							Description: "only cocktails over this ABV percentage",
+							Required:    false,
+						},
+					},
+				},
+				{
+					Name:        "tags",
+					Description: "list the tags in use, or the cocktails with a tag",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionString,
+							Name:         "tag",
+							Description:  "list the cocktails with this tag",
+							Required:     false,
+							Autocomplete: true,
+						},
+					},
+				},
+				{
+					Name:        "history",
+					Description: "list the saved versions of a cocktail's spec",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionString,
+							Name:         "name",
+							Description:  "name of the cocktail",
+							Required:     true,
+							Autocomplete: true,
+						},
+					},
+				},
+				{
+					Name:        "makeable",
+					Description: "list the cocktails you can make with your bar",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+				},
+				{
+					Name:        "batch",
+					Description: "scale a cocktail up to a batch for a pitcher or bottling",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionString,
+							Name:         "name",
+							Description:  "name of the cocktail to batch",
+							Required:     true,
+							Autocomplete: true,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "servings",
+							Description: "number of servings to make",
+							Required:    true,
+							MinValue:    &minServings,
+							MaxValue:    maxServings,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "dilution",
+							Description: "percent water to add to replace shaking or stirring, estimated if not set",
+							Required:    false,
+							MinValue:    &minDilution,
+							MaxValue:    maxDilution,
+						},
+					},
+				},
 				{
 					Name:        "search-ingredients",
 					Description: "search for cocktails by ingredients",
@@ -77,39 +330,186 @@ var (
 			},
 		},
 		{
-			Name:        "proposals",
-			Description: "cocktail proposal commands",
+			Name:        "bar",
+			Description: "manage your home bar",
 			Options: []*discordgo.ApplicationCommandOption{
 				{
-					Name:        "create",
-					Description: "propose a new spec",
+					Name:        "add",
+					Description: "add ingredients to your bar",
 					Type:        discordgo.ApplicationCommandOptionSubCommand,
 					Options: []*discordgo.ApplicationCommandOption{
 						{
 							Type:        discordgo.ApplicationCommandOptionString,
-							Name:        "name",
-							Description: "name of the cocktail",
+							Name:        "items",
+							Description: "comma seperated list of ingredients",
 							Required:    true,
 						},
+					},
+				},
+				{
+					Name:        "remove",
+					Description: "remove ingredients from your bar",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
 						{
 							Type:        discordgo.ApplicationCommandOptionString,
-							Name:        "ingredients",
-							Description: "comma delineated list of ingredients",
+							Name:        "items",
+							Description: "comma seperated list of ingredients",
 							Required:    true,
 						},
+					},
+				},
+				{
+					Name:        "list",
+					Description: "list the ingredients in your bar",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+				},
+			},
+		},
+		{
+			Name:        "fav",
+			Description: "manage your favorite cocktails",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        "add",
+					Description: "add a cocktail to your favorites",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionString,
+							Name:         "name",
+							Description:  "name of the cocktail",
+							Required:     true,
+							Autocomplete: true,
+						},
+					},
+				},
+				{
+					Name:        "remove",
+					Description: "remove a cocktail from your favorites",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionString,
+							Name:         "name",
+							Description:  "name of the cocktail",
+							Required:     true,
+							Autocomplete: true,
+						},
+					},
+				},
+				{
+					Name:        "list",
+					Description: "list your favorite cocktails",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+				},
+			},
+		},
+		{
+			Name:        "collection",
+			Description: "manage your named collections of cocktails",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        "create",
+					Description: "start a new collection",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
 						{
 							Type:        discordgo.ApplicationCommandOptionString,
-							Name:        "instructions",
-							Description: "comma delineated list of ingredients",
+							Name:        "collection",
+							Description: "name of the collection, like Summer patio",
 							Required:    true,
 						},
+					},
+				},
+				{
+					Name:        "delete",
+					Description: "delete one of your collections",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionString,
+							Name:         "collection",
+							Description:  "name of the collection",
+							Required:     true,
+							Autocomplete: true,
+						},
+					},
+				},
+				{
+					Name:        "add",
+					Description: "add a cocktail to one of your collections",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionString,
+							Name:         "collection",
+							Description:  "name of the collection",
+							Required:     true,
+							Autocomplete: true,
+						},
+						{
+							Type:         discordgo.ApplicationCommandOptionString,
+							Name:         "name",
+							Description:  "name of the cocktail",
+							Required:     true,
+							Autocomplete: true,
+						},
+					},
+				},
+				{
+					Name:        "remove",
+					Description: "remove a cocktail from one of your collections",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionString,
+							Name:         "collection",
+							Description:  "name of the collection",
+							Required:     true,
+							Autocomplete: true,
+						},
+						{
+							Type:         discordgo.ApplicationCommandOptionString,
+							Name:         "name",
+							Description:  "name of the cocktail",
+							Required:     true,
+							Autocomplete: true,
+						},
+					},
+				},
+				{
+					Name:        "list",
+					Description: "list your collections, or the cocktails in one",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionString,
+							Name:         "collection",
+							Description:  "name of the collection",
+							Required:     false,
+							Autocomplete: true,
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:        "proposals",
+			Description: "cocktail proposal commands",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        "create",
+					Description: "propose a new spec, opens a form to fill in",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: append([]*discordgo.ApplicationCommandOption{
 						{
 							Type:        discordgo.ApplicationCommandOptionString,
-							Name:        "garnish",
-							Description: "garnish",
+							Name:        "tags",
+							Description: "comma separated tags, like sour, tiki or gin",
 							Required:    false,
 						},
-					},
+					}, append(servingOptions(false), attributionOptions()...)...),
 				},
 				{
 					Name:        "create-variation",
@@ -117,10 +517,11 @@ var (
 					Type:        discordgo.ApplicationCommandOptionSubCommand,
 					Options: []*discordgo.ApplicationCommandOption{
 						{
-							Type:        discordgo.ApplicationCommandOptionString,
-							Name:        "name",
-							Description: "name of the cocktail",
-							Required:    true,
+							Type:         discordgo.ApplicationCommandOptionString,
+							Name:         "name",
+							Description:  "name of the cocktail",
+							Required:     true,
+							Autocomplete: true,
 						},
 						{
 							Type:        discordgo.ApplicationCommandOptionString,
@@ -136,10 +537,11 @@ var (
 					Type:        discordgo.ApplicationCommandOptionSubCommand,
 					Options: []*discordgo.ApplicationCommandOption{
 						{
-							Type:        discordgo.ApplicationCommandOptionString,
-							Name:        "name",
-							Description: "name of the proposal",
-							Required:    true,
+							Type:         discordgo.ApplicationCommandOptionString,
+							Name:         "name",
+							Description:  "name of the proposal",
+							Required:     true,
+							Autocomplete: true,
 						},
 					},
 				},
@@ -149,10 +551,11 @@ var (
 					Type:        discordgo.ApplicationCommandOptionSubCommand,
 					Options: []*discordgo.ApplicationCommandOption{
 						{
-							Type:        discordgo.ApplicationCommandOptionString,
-							Name:        "name",
-							Description: "name of the proposal",
-							Required:    true,
+							Type:         discordgo.ApplicationCommandOptionString,
+							Name:         "name",
+							Description:  "name of the proposal",
+							Required:     true,
+							Autocomplete: true,
 						},
 					},
 				},
@@ -161,11 +564,18 @@ var (
 					Description: "deny a proposed spec",
 					Type:        discordgo.ApplicationCommandOptionSubCommand,
 					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionString,
+							Name:         "name",
+							Description:  "name of the proposal",
+							Required:     true,
+							Autocomplete: true,
+						},
 						{
 							Type:        discordgo.ApplicationCommandOptionString,
-							Name:        "name",
-							Description: "name of the proposal",
-							Required:    true,
+							Name:        "reason",
+							Description: "reason given to the submitter",
+							Required:    false,
 						},
 					},
 				},
@@ -174,11 +584,72 @@ var (
 					Description: "deny a proposed variation",
 					Type:        discordgo.ApplicationCommandOptionSubCommand,
 					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionString,
+							Name:         "name",
+							Description:  "name of the proposal",
+							Required:     true,
+							Autocomplete: true,
+						},
 						{
 							Type:        discordgo.ApplicationCommandOptionString,
-							Name:        "name",
-							Description: "name of the proposal",
-							Required:    true,
+							Name:        "reason",
+							Description: "reason given to the submitter",
+							Required:    false,
+						},
+					},
+				},
+				{
+					Name:        "edit",
+					Description: "propose changes to an existing spec, opens a form pre-filled with it",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: append([]*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionString,
+							Name:         "name",
+							Description:  "name of the cocktail",
+							Required:     true,
+							Autocomplete: true,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "tags",
+							Description: "comma separated tags to replace the current ones with",
+							Required:    false,
+						},
+					}, append(servingOptions(false), attributionOptions()...)...),
+				},
+				{
+					Name:        "approve-edit",
+					Description: "approve a proposed edit",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionString,
+							Name:         "name",
+							Description:  "name of the cocktail",
+							Required:     true,
+							Autocomplete: true,
+						},
+					},
+				},
+				{
+					Name:        "deny-edit",
+					Description: "deny a proposed edit",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionString,
+							Name:         "name",
+							Description:  "name of the cocktail",
+							Required:     true,
+							Autocomplete: true,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "reason",
+							Description: "reason given to the submitter",
+							Required:    false,
 						},
 					},
 				},
@@ -192,47 +663,177 @@ var (
 					Description: "list the current variation proposals",
 					Type:        discordgo.ApplicationCommandOptionSubCommand,
 				},
+				{
+					Name:        "list-edits",
+					Description: "list the current edit proposals",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+				},
+			},
+		},
+		{
+			Name:        "admin",
+			Description: "server administration commands",
+			// Not limited to administrators by default as approvers use it
+			// too, each subcommand checks who is allowed.
+			DMPermission: &dmPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        "rollback",
+					Description: "restore an earlier version of a cocktail's spec",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionString,
+							Name:         "name",
+							Description:  "name of the cocktail",
+							Required:     true,
+							Autocomplete: true,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "version",
+							Description: "version number from /cocktail history",
+							Required:    true,
+						},
+					},
+				},
+				{
+					Name:        "delete-note",
+					Description: "delete a tasting note from a cocktail",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionString,
+							Name:         "name",
+							Description:  "name of the cocktail",
+							Required:     true,
+							Autocomplete: true,
+						},
+						{
+							Type:         discordgo.ApplicationCommandOptionString,
+							Name:         "note",
+							Description:  "ID of the note, shown after it in /cocktail notes",
+							Required:     true,
+							Autocomplete: true,
+						},
+					},
+				},
+				{
+					Name:        "notifications",
+					Description: "choose how submitters hear about decisions on their proposals",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "mode",
+							Description: "where to notify submitters",
+							Required:    true,
+							Choices: []*discordgo.ApplicationCommandOptionChoice{
+								{Name: "direct message", Value: notifyDM},
+								{Name: "mention in the channel the proposal came from", Value: notifyChannel},
+							},
+						},
+					},
+				},
+				{
+					Name:        "approvers",
+					Description: "manage who can approve proposals in this server",
+					Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Name:        "add",
+							Description: "allow a user or role to approve proposals",
+							Type:        discordgo.ApplicationCommandOptionSubCommand,
+							Options: []*discordgo.ApplicationCommandOption{
+								{
+									Type:        discordgo.ApplicationCommandOptionUser,
+									Name:        "user",
+									Description: "user to allow",
+									Required:    false,
+								},
+								{
+									Type:        discordgo.ApplicationCommandOptionRole,
+									Name:        "role",
+									Description: "role to allow",
+									Required:    false,
+								},
+							},
+						},
+						{
+							Name:        "remove",
+							Description: "stop a user or role from approving proposals",
+							Type:        discordgo.ApplicationCommandOptionSubCommand,
+							Options: []*discordgo.ApplicationCommandOption{
+								{
+									Type:        discordgo.ApplicationCommandOptionUser,
+									Name:        "user",
+									Description: "user to remove",
+									Required:    false,
+								},
+								{
+									Type:        discordgo.ApplicationCommandOptionRole,
+									Name:        "role",
+									Description: "role to remove",
+									Required:    false,
+								},
+							},
+						},
+						{
+							Name:        "list",
+							Description: "list who can approve proposals",
+							Type:        discordgo.ApplicationCommandOptionSubCommand,
+						},
+					},
+				},
 			},
 		},
 	}
-)
 
-type waitingApproval struct {
-	pending map[string]*spec
-	sync.Mutex
-}
+	dmPermission = false
+)
 
-func (a *waitingApproval) list() (ret []*spec) {
-	a.Lock()
-	defer a.Unlock()
-	for _, v := range a.pending {
-		ret = append(ret, v)
+func random(ctx context.Context, store CatalogStore, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	var cocktails []string
+	if f := filterOptions(i); f.empty() {
+		var err error
+		if cocktails, err = listCocktails(ctx, store); err != nil {
+			logInteractionError(s, i.Interaction, err)
+			return
+		}
+	} else if cocktails = f.cocktails(); len(cocktails) == 0 {
+		respond(s, i.Interaction, fmt.Sprintf("No cocktails with %s, try /cocktail tags to see what's there", f), nil, true)
+		return
 	}
-	return
-}
 
-func (a *waitingApproval) get(k string) (*spec, bool) {
-	a.Lock()
-	defer a.Unlock()
-	v, ok := a.pending[k]
-	return v, ok
-}
-
-func (a *waitingApproval) add(k string, v *spec) {
-	a.Lock()
-	defer a.Unlock()
-	a.pending[k] = v
-}
-
-func (a *waitingApproval) remove(k string) {
-	a.Lock()
-	defer a.Unlock()
-	delete(a.pending, k)
-}
+	if opt, ok := subcommandOptions(i)["from"]; ok {
+		from, msg, err := randomFrom(ctx, store, interactionUser(i).ID, opt.StringValue())
+		if err != nil {
+			logInteractionError(s, i.Interaction, err)
+			return
+		}
+		if msg != "" {
+			respond(s, i.Interaction, msg, nil, true)
+			return
+		}
+		allowed := map[string]bool{}
+		for _, c := range cocktails {
+			allowed[c] = true
+		}
+		cocktails = nil
+		for _, c := range from {
+			if allowed[c] {
+				cocktails = append(cocktails, c)
+			}
+		}
+		if len(cocktails) == 0 {
+			respond(s, i.Interaction, fmt.Sprintf("None of the cocktails in %s match", opt.StringValue()), nil, true)
+			return
+		}
+	}
 
-func random(ctx context.Context, client *storage.Client, s *discordgo.Session, i *discordgo.InteractionCreate) {
 	var files []*discordgo.File
-	sp, pic, closer, err := randomCocktail(ctx, client)
+	cocktail := randomCocktail(cocktails)
+	sp, pic, closer, err := getCocktail(ctx, store, cocktail)
 	if err != nil {
 		logInteractionError(s, i.Interaction, err)
 		return
@@ -243,113 +844,87 @@ func random(ctx context.Context, client *storage.Client, s *discordgo.Session, i
 			pic,
 		}
 	}
-	respond(s, i.Interaction, sp.String(), files, false)
+	e := sp.embed(unitsFor(ctx, store, i), pic)
+	e.Fields = append(e.Fields, &discordgo.MessageEmbedField{Name: "Rating", Value: ratingSummary(cocktail)})
+	respondEmbed(s, i.Interaction, []*discordgo.MessageEmbed{e}, files, false)
 }
 
-func createCocktail(ctx context.Context, client *storage.Client, name string, data []byte) error {
-	writer := client.Bucket(*bucket).Object(path.Join(name, "spec")).NewWriter(ctx)
-	if _, err := io.Copy(writer, bytes.NewReader(data)); err != nil {
-		return err
-	}
-	return writer.Close()
+func createCocktail(ctx context.Context, store CatalogStore, name string, data []byte) error {
+	return store.WriteSpec(ctx, name, data)
 }
 
-func listCocktails(ctx context.Context, client *storage.Client) ([]string, error) {
-	bkt := client.Bucket(*bucket)
-	query := &storage.Query{Delimiter: "/"}
-	query.SetAttrSelection([]string{"Prefix"})
-
-	var cocktails []string
-	it := bkt.Objects(ctx, query)
-	for {
-		attrs, err := it.Next()
-		if err == iterator.Done {
-			break
-		}
-		if err != nil {
-			return nil, err
-		}
-		cocktails = append(cocktails, strings.TrimSuffix(attrs.Prefix, "/"))
-	}
-	return cocktails, nil
+func listCocktails(ctx context.Context, store CatalogStore) ([]string, error) {
+	return store.ListCocktails(ctx)
 }
 
-func getSpec(ctx context.Context, client *storage.Client, prefix string) (*spec, error) {
-	reader, err := client.Bucket(*bucket).Object(path.Join(prefix, "spec")).NewReader(ctx)
-	if err != nil {
-		return nil, err
-	}
-	defer reader.Close()
-	data, err := ioutil.ReadAll(reader)
+func getSpec(ctx context.Context, store CatalogStore, prefix string) (*spec, error) {
+	data, err := store.ReadSpec(ctx, prefix)
 	if err != nil {
 		return nil, err
 	}
 	return parseSpec(data)
 }
 
-func randomPic(ctx context.Context, client *storage.Client, prefix string) (*discordgo.File, func() error, error) {
-	bkt := client.Bucket(*bucket)
-	prefix = path.Join(prefix, "pictures")
-	query := &storage.Query{Prefix: prefix}
-	query.SetAttrSelection([]string{"Name"})
-
-	var pics []string
-	it := bkt.Objects(ctx, query)
-	for {
-		attrs, err := it.Next()
-		if err == iterator.Done {
-			break
+// migrateSpecs rewrites every spec in the store, converting the plain string
+// ingredients older specs were written with into structured ingredients.
+func migrateSpecs(ctx context.Context, store CatalogStore) error {
+	cocktails, err := listCocktails(ctx, store)
+	if err != nil {
+		return err
+	}
+	for _, cocktail := range cocktails {
+		sp, err := getSpec(ctx, store, cocktail)
+		if err != nil {
+			return fmt.Errorf("error reading spec for %q: %v", cocktail, err)
 		}
+		data, err := json.Marshal(sp)
 		if err != nil {
-			return nil, nil, err
+			return err
 		}
-		if attrs.Name == prefix+"/" {
-			continue
+		if err := createCocktail(ctx, store, cocktail, data); err != nil {
+			return fmt.Errorf("error writing spec for %q: %v", cocktail, err)
 		}
-		pics = append(pics, attrs.Name)
+		log.Printf("Migrated %q", cocktail)
+	}
+	return nil
+}
+
+func randomPic(ctx context.Context, store CatalogStore, prefix string) (*discordgo.File, func() error, error) {
+	pics, err := store.ListPictures(ctx, prefix)
+	if err != nil {
+		return nil, nil, err
 	}
 	if len(pics) == 0 {
 		log.Printf("No pictures for %q", prefix)
 		return nil, nil, nil
 	}
 	name := pics[rand.Intn(len(pics))]
-	fmt.Println("pic name:", name)
-
-	attrs, err := bkt.Object(name).Attrs(ctx)
+	reader, contentType, err := store.ReadPicture(ctx, prefix, name)
 	if err != nil {
 		return nil, nil, err
 	}
 
 	var sFile discordgo.File
-	sFile.ContentType = attrs.ContentType
-	sFile.Name = path.Base(attrs.Name)
-
-	reader, err := bkt.Object(name).NewReader(ctx)
-	if err != nil {
-		return nil, nil, err
-	}
+	sFile.ContentType = contentType
+	sFile.Name = attachmentName(path.Base(name))
 	sFile.Reader = reader
 	return &sFile, reader.Close, nil
 }
 
-func getCocktail(ctx context.Context, client *storage.Client, cocktail string) (*spec, *discordgo.File, func() error, error) {
-	sp, err := getSpec(ctx, client, cocktail)
+func getCocktail(ctx context.Context, store CatalogStore, cocktail string) (*spec, *discordgo.File, func() error, error) {
+	sp, err := getSpec(ctx, store, cocktail)
 	if err != nil {
 		return nil, nil, nil, err
 	}
 
-	f, closer, err := randomPic(ctx, client, cocktail)
+	f, closer, err := randomPic(ctx, store, cocktail)
 	return sp, f, closer, err
 }
 
-func randomCocktail(ctx context.Context, client *storage.Client) (*spec, *discordgo.File, func() error, error) {
-	cocktails, err := listCocktails(ctx, client)
-	if err != nil {
-		return nil, nil, nil, err
-	}
+// randomCocktail picks one of cocktails at random.
+func randomCocktail(cocktails []string) string {
 	rand.Seed(time.Now().UnixNano())
-	prefix := cocktails[rand.Intn(len(cocktails))]
-	return getCocktail(ctx, client, prefix)
+	return cocktails[rand.Intn(len(cocktails))]
 }
 
 func normalizeName(name string) string {
@@ -360,11 +935,31 @@ func main() {
 	ctx := context.Background()
 	flag.Parse()
 
-	gcsClient, err := storage.NewClient(ctx)
+	store, err := newStore(ctx, *storeType)
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	if *migrate {
+		if err := migrateSpecs(ctx, store); err != nil {
+			log.Fatalf("Error migrating specs: %v", err)
+		}
+		return
+	}
+
+	if err := loadProposals(ctx, store); err != nil {
+		log.Fatalf("Error loading proposals: %v", err)
+	}
+
+	if err := catalog.rebuild(ctx, store); err != nil {
+		log.Fatalf("Error building catalog index: %v", err)
+	}
+	go catalog.refresh(ctx, store, *refresh)
+
+	if err := ratings.load(ctx, store); err != nil {
+		log.Fatalf("Error loading ratings: %v", err)
+	}
+
 	s, err := discordgo.New("Bot " + *token)
 	if err != nil {
 		log.Fatalf("Invalid bot parameters: %v", err)
@@ -372,10 +967,10 @@ func main() {
 
 	// Start handlers.
 	s.AddHandler(func(s *discordgo.Session, i *discordgo.InteractionCreate) {
-		baseHandler(ctx, gcsClient, s, i)
+		baseHandler(ctx, store, s, i)
 	})
 	s.AddHandler(func(s *discordgo.Session, m *discordgo.MessageCreate) {
-		messageCreate(ctx, gcsClient, s, m)
+		messageCreate(ctx, store, s, m)
 	})
 	s.Identify.Intents = discordgo.IntentsGuildMessages
 
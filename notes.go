@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// maxNoteLength keeps tasting notes short enough to show a few under a
+	// spec.
+	maxNoteLength = 300
+	// latestNotes is how many notes /cocktail search shows.
+	latestNotes = 3
+)
+
+// tastingNote is a user's comment on a cocktail, kept at
+// <name>/tasting-notes/<ID>.json. The ID is the time it was written, so it
+// stays the same as other notes come and go.
+type tastingNote struct {
+	ID       string `json:"-"`
+	Written  time.Time
+	AuthorID string
+	Author   string
+	// GuildID is where the note was written, its approvers may delete it.
+	GuildID string `json:",omitempty"`
+	Text    string
+}
+
+func tastingNotesPrefix(cocktail string) string {
+	return path.Join(cocktail, "tasting-notes")
+}
+
+func noteKey(cocktail, id string) string {
+	return path.Join(tastingNotesPrefix(cocktail), id+".json")
+}
+
+// listNotes returns the tasting note keys of a cocktail, oldest first.
+func listNotes(ctx context.Context, store CatalogStore, cocktail string) ([]string, error) {
+	keys, err := store.ListObjects(ctx, tastingNotesPrefix(cocktail))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func readNote(ctx context.Context, store CatalogStore, key string) (*tastingNote, error) {
+	data, err := store.ReadObject(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	var n tastingNote
+	if err := json.Unmarshal(data, &n); err != nil {
+		return nil, fmt.Errorf("error parsing tasting note %q: %v", key, err)
+	}
+	n.ID = strings.TrimSuffix(path.Base(key), ".json")
+	return &n, nil
+}
+
+func writeNote(ctx context.Context, store CatalogStore, cocktail string, n *tastingNote) error {
+	data, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	return store.WriteObject(ctx, noteKey(cocktail, n.ID), data)
+}
+
+// describe is how a note is shown, with the ID approvers delete it by.
+func (t *tastingNote) describe() string {
+	return fmt.Sprintf("<@%s>, %s: %s `%s`", t.AuthorID, t.Written.Format("2006-01-02"), t.Text, t.ID)
+}
+
+// recentNotes describes the latest few tasting notes on a cocktail, newest
+// first, for showing under the spec.
+func recentNotes(ctx context.Context, store CatalogStore, cocktail string) (string, error) {
+	keys, err := listNotes(ctx, store, cocktail)
+	if err != nil {
+		return "", err
+	}
+	if len(keys) == 0 {
+		return "None yet, add one with /cocktail note", nil
+	}
+	var lines []string
+	for n := len(keys) - 1; n >= 0 && n >= len(keys)-latestNotes; n-- {
+		t, err := readNote(ctx, store, keys[n])
+		if err != nil {
+			return "", err
+		}
+		lines = append(lines, t.describe())
+	}
+	if len(keys) > latestNotes {
+		lines = append(lines, fmt.Sprintf("See all %d with /cocktail notes", len(keys)))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// addNote records a tasting note from the user on a cocktail.
+func addNote(ctx context.Context, store CatalogStore, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	opts := subcommandOptions(i)
+	name := opts["name"].StringValue()
+	text := strings.Join(strings.Fields(opts["text"].StringValue()), " ")
+	if text == "" {
+		respond(s, i.Interaction, "A tasting note can't be empty", nil, true)
+		return
+	}
+	if len([]rune(text)) > maxNoteLength {
+		respond(s, i.Interaction, fmt.Sprintf("Tasting notes can be at most %d characters", maxNoteLength), nil, true)
+		return
+	}
+
+	cocktails, err := listCocktails(ctx, store)
+	if err != nil {
+		logInteractionError(s, i.Interaction, err)
+		return
+	}
+	found, suggestions := findCocktail(name, cocktails, catalog.aliasNames())
+	if found == "" {
+		respond(s, i.Interaction, didYouMean(name, suggestions), nil, true)
+		return
+	}
+
+	user := interactionUser(i)
+	now := time.Now().UTC()
+	t := &tastingNote{
+		ID:       now.Format(versionFormat),
+		Written:  now,
+		AuthorID: user.ID,
+		Author:   user.Username,
+		GuildID:  i.GuildID,
+		Text:     text,
+	}
+	if err := writeNote(ctx, store, found, t); err != nil {
+		logInteractionError(s, i.Interaction, err)
+		return
+	}
+	respond(s, i.Interaction, fmt.Sprintf("Added your note to %s", found), nil, true)
+}
+
+// listTastingNotes lists every tasting note on a cocktail, newest first.
+func listTastingNotes(ctx context.Context, store CatalogStore, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	name := subcommandOptions(i)["name"].StringValue()
+	cocktails, err := listCocktails(ctx, store)
+	if err != nil {
+		logInteractionError(s, i.Interaction, err)
+		return
+	}
+	found, suggestions := findCocktail(name, cocktails, catalog.aliasNames())
+	if found == "" {
+		respond(s, i.Interaction, didYouMean(name, suggestions), nil, true)
+		return
+	}
+
+	keys, err := listNotes(ctx, store, found)
+	if err != nil {
+		logInteractionError(s, i.Interaction, err)
+		return
+	}
+	if len(keys) == 0 {
+		respond(s, i.Interaction, fmt.Sprintf("No tasting notes on %s yet, add one with /cocktail note", found), nil, true)
+		return
+	}
+
+	var items []string
+	for n := len(keys) - 1; n >= 0; n-- {
+		t, err := readNote(ctx, store, keys[n])
+		if err != nil {
+			logInteractionError(s, i.Interaction, err)
+			return
+		}
+		items = append(items, t.describe())
+	}
+	respondPages(s, i.Interaction, fmt.Sprintf("%d tasting notes on %s:\n", len(keys), found), items, true)
+}
+
+// adminDeleteNote removes a tasting note, for approvers of the guild it was
+// written in and the bot owner to clean up abuse.
+func adminDeleteNote(ctx context.Context, store CatalogStore, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	opts := subcommandOptions(i)
+	name := opts["name"].StringValue()
+	id := strings.TrimSpace(opts["note"].StringValue())
+
+	cocktails, err := listCocktails(ctx, store)
+	if err != nil {
+		logInteractionError(s, i.Interaction, err)
+		return
+	}
+	found, suggestions := findCocktail(name, cocktails, catalog.aliasNames())
+	if found == "" {
+		respond(s, i.Interaction, didYouMean(name, suggestions), nil, true)
+		return
+	}
+	if id == "" || strings.ContainsAny(id, "/\\") {
+		respond(s, i.Interaction, fmt.Sprintf("%q isn't a note ID, see /cocktail notes", id), nil, true)
+		return
+	}
+	key := noteKey(found, id)
+	t, err := readNote(ctx, store, key)
+	if err == errNotFound {
+		respond(s, i.Interaction, fmt.Sprintf("%s has no note %s, it may already be deleted", found, id), nil, true)
+		return
+	}
+	if err != nil {
+		logInteractionError(s, i.Interaction, err)
+		return
+	}
+	if !checkGuildApprover(ctx, store, s, i, t.GuildID) {
+		return
+	}
+	if err := store.DeleteObject(ctx, key); err != nil {
+		logInteractionError(s, i.Interaction, err)
+		return
+	}
+	respond(s, i.Interaction, fmt.Sprintf("Deleted note %s on %s by %s: %s", id, found, t.Author, t.Text), nil, true)
+}
+
+// noteChoices returns the IDs of the tasting notes on a cocktail, newest
+// first, labelled with who wrote them and how they start.
+func noteChoices(ctx context.Context, store CatalogStore, cocktail string) ([]string, map[string]string, error) {
+	keys, err := listNotes(ctx, store, cocktail)
+	if err != nil {
+		return nil, nil, err
+	}
+	var ids []string
+	labels := map[string]string{}
+	for n := len(keys) - 1; n >= 0 && len(ids) < maxChoices; n-- {
+		t, err := readNote(ctx, store, keys[n])
+		if err != nil {
+			return nil, nil, err
+		}
+		ids = append(ids, t.ID)
+		labels[t.ID] = choiceLabel(fmt.Sprintf("%s: %s", t.Author, t.Text))
+	}
+	return ids, labels, nil
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// maxPageLength leaves room under Discord's 2000 character limit for the
+	// page footer.
+	maxPageLength = 1900
+	// pageTimeout is how long the buttons on a paged message keep working.
+	pageTimeout  = 15 * time.Minute
+	pageButtonID = "page"
+	pagePrevious = "prev"
+	pageNext     = "next"
+	pageExpired  = "expired"
+)
+
+// pagedMessage is the state of a paged response, keyed by the ID of the
+// interaction that created it.
+type pagedMessage struct {
+	pages   []string
+	page    int
+	expires time.Time
+}
+
+type paginator struct {
+	messages map[string]*pagedMessage
+	sync.Mutex
+}
+
+var pager = paginator{messages: map[string]*pagedMessage{}}
+
+// paginate packs the header and items into pages, items are only split
+// across pages if a single item is too long for a page.
+func paginate(header string, items []string) []string {
+	var pages []string
+	page := header
+	for _, item := range items {
+		if len(page)+len(item)+1 > maxPageLength && strings.TrimSpace(page) != "" {
+			pages = append(pages, page)
+			page = ""
+		}
+		for len(item) > maxPageLength {
+			cut := strings.LastIndex(item[:maxPageLength], "\n")
+			if cut <= 0 {
+				cut = maxPageLength
+				for !utf8.RuneStart(item[cut]) {
+					cut--
+				}
+			}
+			pages = append(pages, item[:cut])
+			item = item[cut:]
+		}
+		page = fmt.Sprintf("%s%s\n", page, item)
+	}
+	if strings.TrimSpace(page) != "" || len(pages) == 0 {
+		pages = append(pages, page)
+	}
+	return pages
+}
+
+// start tracks a new paged message, clearing out any that have expired.
+func (p *paginator) start(id string, pages []string) *pagedMessage {
+	p.Lock()
+	defer p.Unlock()
+	now := time.Now()
+	for k, m := range p.messages {
+		if now.After(m.expires) {
+			delete(p.messages, k)
+		}
+	}
+	m := &pagedMessage{pages: pages, expires: now.Add(pageTimeout)}
+	p.messages[id] = m
+	return m
+}
+
+// turn moves a paged message forward or back, returning false if the message
+// has expired.
+func (p *paginator) turn(id, direction string) (string, []discordgo.MessageComponent, bool) {
+	p.Lock()
+	defer p.Unlock()
+	m, ok := p.messages[id]
+	if !ok || time.Now().After(m.expires) {
+		delete(p.messages, id)
+		return "", nil, false
+	}
+	switch direction {
+	case pagePrevious:
+		if m.page > 0 {
+			m.page--
+		}
+	case pageNext:
+		if m.page < len(m.pages)-1 {
+			m.page++
+		}
+	}
+	return m.content(), m.components(id), true
+}
+
+func (m *pagedMessage) content() string {
+	return fmt.Sprintf("%s\n*Page %d of %d*", m.pages[m.page], m.page+1, len(m.pages))
+}
+
+func (m *pagedMessage) components(id string) []discordgo.MessageComponent {
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    "Previous",
+					Style:    discordgo.SecondaryButton,
+					Disabled: m.page == 0,
+					CustomID: strings.Join([]string{pageButtonID, id, pagePrevious}, ":"),
+				},
+				discordgo.Button{
+					Label:    "Next",
+					Style:    discordgo.SecondaryButton,
+					Disabled: m.page == len(m.pages)-1,
+					CustomID: strings.Join([]string{pageButtonID, id, pageNext}, ":"),
+				},
+			},
+		},
+	}
+}
+
+// respondPages responds with the header and items, split into pages with
+// navigation buttons if they don't fit in one message.
+func respondPages(s *discordgo.Session, i *discordgo.Interaction, header string, items []string, ephemeral bool) bool {
+	pages := paginate(header, items)
+	if len(pages) == 1 {
+		return respond(s, i, pages[0], nil, ephemeral)
+	}
+	var flags discordgo.MessageFlags
+	if ephemeral {
+		flags = discordgo.MessageFlagsEphemeral
+	}
+	m := pager.start(i.ID, pages)
+	if err := s.InteractionRespond(i, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags:      flags,
+			Content:    m.content(),
+			Components: m.components(i.ID),
+		},
+	}); err != nil {
+		logInteractionError(s, i, err)
+		return false
+	}
+	return true
+}
+
+// editPages is respondPages for interactions that were already deferred.
+func editPages(s *discordgo.Session, i *discordgo.Interaction, header string, items []string) bool {
+	pages := paginate(header, items)
+	content := pages[0]
+	var components []discordgo.MessageComponent
+	if len(pages) > 1 {
+		m := pager.start(i.ID, pages)
+		content = m.content()
+		components = m.components(i.ID)
+	}
+	if _, err := s.InteractionResponseEdit(i, &discordgo.WebhookEdit{
+		Content:    &content,
+		Components: &components,
+	}); err != nil {
+		logInteractionError(s, i, err)
+		return false
+	}
+	return true
+}
+
+// pageButton handles the Previous and Next buttons on paged messages.
+func pageButton(s *discordgo.Session, i *discordgo.InteractionCreate, id, direction string) {
+	content, components, ok := pager.turn(id, direction)
+	if !ok {
+		// The page may already be as long as a message can be, so say it
+		// expired on the button rather than in the content.
+		content = i.Message.Content
+		components = []discordgo.MessageComponent{
+			discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					discordgo.Button{
+						Label:    "Expired, run the command again to page through",
+						Style:    discordgo.SecondaryButton,
+						Disabled: true,
+						CustomID: strings.Join([]string{pageButtonID, id, pageExpired}, ":"),
+					},
+				},
+			},
+		}
+	}
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    content,
+			Components: components,
+		},
+	}); err != nil {
+		logInteractionError(s, i.Interaction, err)
+	}
+}
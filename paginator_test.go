@@ -0,0 +1,41 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPaginateLongItem(t *testing.T) {
+	for _, n := range []int{maxPageLength - 1, maxPageLength, maxPageLength + 1} {
+		item := strings.Repeat("a", n)
+		pages := paginate("", []string{item})
+		var got string
+		for _, p := range pages {
+			if len(p) > maxPageLength+1 {
+				t.Errorf("paginate(%d bytes): page of %d bytes is too long", n, len(p))
+			}
+			got += strings.TrimSuffix(p, "\n")
+		}
+		if got != item {
+			t.Errorf("paginate(%d bytes) lost content, got %d bytes back", n, len(got))
+		}
+		if want := 1 + (n-1)/maxPageLength; len(pages) != want {
+			t.Errorf("paginate(%d bytes) = %d pages, want %d", n, len(pages), want)
+		}
+	}
+}
+
+func TestPaginateSplitsOnRuneStart(t *testing.T) {
+	item := strings.Repeat("é", maxPageLength)
+	pages := paginate("", []string{item})
+	var got string
+	for _, p := range pages {
+		if !strings.HasPrefix(p, "é") {
+			t.Errorf("page starts mid rune: %q", p[:2])
+		}
+		got += strings.TrimSuffix(p, "\n")
+	}
+	if got != item {
+		t.Errorf("paginate lost content, got %d bytes back, want %d", len(got), len(item))
+	}
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+var guildsPrefix = path.Join(metaPrefix, "guilds")
+
+// Ways of telling submitters about decisions on their proposals.
+const (
+	notifyDM      = "dm"
+	notifyChannel = "channel"
+)
+
+// guildConfig is the per guild configuration, stored at
+// _meta/guilds/<id>/config.
+type guildConfig struct {
+	// ApproverUsers and ApproverRoles may approve proposals from this guild
+	// and manage the specs and notes that came from it.
+	ApproverUsers []string `json:",omitempty"`
+	ApproverRoles []string `json:",omitempty"`
+	// Notifications is notifyDM or notifyChannel, DMs are the default.
+	Notifications string `json:",omitempty"`
+}
+
+func guildConfigKey(guildID string) string {
+	return path.Join(guildsPrefix, guildID, "config")
+}
+
+func getGuildConfig(ctx context.Context, store CatalogStore, guildID string) (*guildConfig, error) {
+	var cfg guildConfig
+	if guildID == "" {
+		return &cfg, nil
+	}
+	data, err := store.ReadObject(ctx, guildConfigKey(guildID))
+	if err == errNotFound {
+		return &cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &cfg, json.Unmarshal(data, &cfg)
+}
+
+func saveGuildConfig(ctx context.Context, store CatalogStore, guildID string, cfg *guildConfig) error {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	return store.WriteObject(ctx, guildConfigKey(guildID), data)
+}
+
+// isApprover reports whether a user may approve proposals and manage the
+// catalog from the given guild. The bot owner is an approver everywhere,
+// including DMs.
+func isApprover(ctx context.Context, store CatalogStore, guildID, userID string, roles []string) (bool, error) {
+	if userID == *owner {
+		return true, nil
+	}
+	cfg, err := getGuildConfig(ctx, store, guildID)
+	if err != nil {
+		return false, err
+	}
+	for _, id := range cfg.ApproverUsers {
+		if id == userID {
+			return true, nil
+		}
+	}
+	for _, want := range cfg.ApproverRoles {
+		for _, role := range roles {
+			if role == want {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// checkGuildApprover is the permission check for acting on something that
+// came from a guild, like a proposal or a tasting note. Approvers are set per
+// guild but the catalog is shared, so only approvers of that guild and the bot
+// owner may act on it. Roles only count in the guild they belong to. It
+// responds to the interaction and returns false if the user isn't allowed.
+func checkGuildApprover(ctx context.Context, store CatalogStore, s *discordgo.Session, i *discordgo.InteractionCreate, guildID string) bool {
+	var roles []string
+	if i.Member != nil && i.GuildID == guildID {
+		roles = i.Member.Roles
+	}
+	ok, err := isApprover(ctx, store, guildID, interactionUser(i).ID, roles)
+	if err != nil {
+		logInteractionError(s, i.Interaction, err)
+		return false
+	}
+	if !ok {
+		respond(s, i.Interaction, "You're not my boss!", nil, true)
+	}
+	return ok
+}
+
+// memberRoles returns the roles a user has in a guild, or nil if they can't be
+// looked up, such as when the user has left.
+func memberRoles(s *discordgo.Session, guildID, userID string) []string {
+	if guildID == "" {
+		return nil
+	}
+	m, err := s.GuildMember(guildID, userID)
+	if err != nil {
+		log.Printf("Error looking up member %q of guild %q: %v", userID, guildID, err)
+		return nil
+	}
+	return m.Roles
+}
+
+// roleMembers returns the IDs of the guild members with any of the roles.
+// Listing members needs the Server Members intent enabled for the bot.
+func roleMembers(s *discordgo.Session, guildID string, roles []string) ([]string, error) {
+	want := map[string]bool{}
+	for _, r := range roles {
+		want[r] = true
+	}
+	var ids []string
+	var after string
+	for {
+		members, err := s.GuildMembers(guildID, after, 1000)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range members {
+			for _, r := range m.Roles {
+				if want[r] {
+					ids = append(ids, m.User.ID)
+					break
+				}
+			}
+		}
+		if len(members) < 1000 {
+			return ids, nil
+		}
+		after = members[len(members)-1].User.ID
+	}
+}
+
+// notifyApprovers DMs the bot owner and the approvers of the guild about a
+// proposal, with buttons to decide on it. The sent DMs are recorded on the
+// proposal.
+func notifyApprovers(ctx context.Context, store CatalogStore, s *discordgo.Session, p *proposal, content string) {
+	guildID := p.GuildID
+	ids := []string{*owner}
+	cfg, err := getGuildConfig(ctx, store, guildID)
+	if err != nil {
+		log.Printf("Error reading config for guild %q: %v", guildID, err)
+	} else {
+		users := cfg.ApproverUsers
+		if len(cfg.ApproverRoles) > 0 {
+			members, err := roleMembers(s, guildID, cfg.ApproverRoles)
+			if err != nil {
+				log.Printf("Error listing approver roles of guild %q: %v", guildID, err)
+			}
+			users = append(users[:len(users):len(users)], members...)
+		}
+		for _, id := range users {
+			ids, _ = addUnique(ids, id)
+		}
+	}
+	var refs []messageRef
+	for _, id := range ids {
+		m := dmComplex(s, id, &discordgo.MessageSend{
+			Content:    content,
+			Components: reviewComponents(p),
+		})
+		if m != nil {
+			refs = append(refs, messageRef{ChannelID: m.ChannelID, MessageID: m.ID})
+		}
+	}
+	if err := queueFor(p.Kind).track(ctx, store, p, refs); err != nil {
+		log.Printf("Error recording approver messages for proposal %q: %v", p.ID, err)
+	}
+}
+
+// addUnique adds v to list if it isn't already there.
+func addUnique(list []string, v string) ([]string, bool) {
+	for _, x := range list {
+		if x == v {
+			return list, false
+		}
+	}
+	return append(list, v), true
+}
+
+// removeValue removes v from list if it is there.
+func removeValue(list []string, v string) ([]string, bool) {
+	for n, x := range list {
+		if x == v {
+			return append(list[:n:n], list[n+1:]...), true
+		}
+	}
+	return list, false
+}
+
+func adminApprovers(ctx context.Context, store CatalogStore, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Member == nil || i.Member.Permissions&discordgo.PermissionAdministrator == 0 {
+		respond(s, i.Interaction, "Only server administrators can manage approvers", nil, true)
+		return
+	}
+
+	sub := i.ApplicationCommandData().Options[0].Options[0]
+	cfg, err := getGuildConfig(ctx, store, i.GuildID)
+	if err != nil {
+		logInteractionError(s, i.Interaction, err)
+		return
+	}
+
+	if sub.Name == "list" {
+		var items []string
+		for _, id := range cfg.ApproverUsers {
+			items = append(items, "    <@"+id+">")
+		}
+		for _, id := range cfg.ApproverRoles {
+			items = append(items, "    <@&"+id+">")
+		}
+		sort.Strings(items)
+		respondPages(s, i.Interaction, fmt.Sprintf("%d approvers, plus the bot owner:\n", len(items)), items, true)
+		return
+	}
+
+	var user *discordgo.User
+	var role *discordgo.Role
+	for _, opt := range sub.Options {
+		switch opt.Name {
+		case "user":
+			user = opt.UserValue(nil)
+		case "role":
+			role = opt.RoleValue(nil, "")
+		}
+	}
+	if user == nil && role == nil {
+		respond(s, i.Interaction, "Give a user or a role", nil, true)
+		return
+	}
+
+	update := addUnique
+	verb := "Added"
+	if sub.Name == "remove" {
+		update = removeValue
+		verb = "Removed"
+	}
+	var changed []string
+	if user != nil {
+		var ok bool
+		if cfg.ApproverUsers, ok = update(cfg.ApproverUsers, user.ID); ok {
+			changed = append(changed, "<@"+user.ID+">")
+		}
+	}
+	if role != nil {
+		var ok bool
+		if cfg.ApproverRoles, ok = update(cfg.ApproverRoles, role.ID); ok {
+			changed = append(changed, "<@&"+role.ID+">")
+		}
+	}
+	if err := saveGuildConfig(ctx, store, i.GuildID, cfg); err != nil {
+		logInteractionError(s, i.Interaction, err)
+		return
+	}
+	respond(s, i.Interaction, fmt.Sprintf("%s %d approvers: %s", verb, len(changed), strings.Join(changed, ", ")), nil, true)
+}
+
+func adminNotifications(ctx context.Context, store CatalogStore, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Member == nil || i.Member.Permissions&discordgo.PermissionAdministrator == 0 {
+		respond(s, i.Interaction, "Only server administrators can change notifications", nil, true)
+		return
+	}
+
+	cfg, err := getGuildConfig(ctx, store, i.GuildID)
+	if err != nil {
+		logInteractionError(s, i.Interaction, err)
+		return
+	}
+	cfg.Notifications = subcommandOptions(i)["mode"].StringValue()
+	if err := saveGuildConfig(ctx, store, i.GuildID, cfg); err != nil {
+		logInteractionError(s, i.Interaction, err)
+		return
+	}
+	content := "Submitters will be sent a DM when their proposals are decided"
+	if cfg.Notifications == notifyChannel {
+		content = "Submitters will be mentioned in the channel they proposed from when their proposals are decided"
+	}
+	respond(s, i.Interaction, content, nil, true)
+}
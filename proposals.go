@@ -0,0 +1,337 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	proposalCreate    = "create"
+	proposalVariation = "variation"
+	proposalEdit      = "edit"
+
+	statusPending  = "pending"
+	statusApproved = "approved"
+	statusDenied   = "denied"
+	// statusChangesRequested closes a proposal the approver sent back to the
+	// submitter for another try.
+	statusChangesRequested = "changes-requested"
+	// statusReplaced is set when the submitter resubmits before a decision.
+	statusReplaced = "replaced"
+)
+
+var proposalsPrefix = path.Join(metaPrefix, "proposals")
+
+// decidedProposalsPrefix is where proposals are moved once they are no longer
+// pending, so startup only has to read the pending ones.
+var decidedProposalsPrefix = path.Join(metaPrefix, "decided-proposals")
+
+// errAlreadyDecided is returned by decide when someone else got to the
+// proposal first.
+var errAlreadyDecided = errors.New("proposal already decided or replaced")
+
+// errAlreadyPending is returned by add when someone else has a proposal for
+// the same cocktail waiting on approval.
+var errAlreadyPending = errors.New("another proposal is already pending")
+
+// proposal is a spec or variation waiting on approval, along with who
+// submitted it and what became of it. Proposals are never deleted from the
+// store, decided ones are moved under decidedProposalsPrefix.
+type proposal struct {
+	ID   string
+	Kind string
+	// Key is the normalized cocktail name this proposal is tracked under.
+	Key  string
+	Spec *spec
+	// Target is the stored cocktail an edit proposal replaces the spec of.
+	Target string `json:",omitempty"`
+
+	SubmitterID string
+	Submitter   string
+	GuildID     string
+	Guild       string
+	// ChannelID is where the proposal was submitted, for notifying the
+	// submitter in the channel.
+	ChannelID string `json:",omitempty"`
+	Created   time.Time
+
+	Status    string
+	DecidedBy string
+	Decided   time.Time
+	// Reason is the approver's optional explanation for the decision.
+	Reason string `json:",omitempty"`
+
+	// ApproverMessages are the DMs sent to approvers about this proposal, so
+	// they can be updated once it is decided.
+	ApproverMessages []messageRef `json:",omitempty"`
+}
+
+// messageRef identifies a sent Discord message.
+type messageRef struct {
+	ChannelID string
+	MessageID string
+}
+
+func newProposal(kind, key string, sp *spec) *proposal {
+	now := time.Now().UTC()
+	return &proposal{
+		// The ID ends up in button custom IDs, which Discord limits to 100
+		// characters, so it can't include the cocktail name.
+		ID:      fmt.Sprintf("%s-%s", kind, strconv.FormatInt(now.UnixNano(), 36)),
+		Kind:    kind,
+		Key:     key,
+		Spec:    sp,
+		Created: now,
+		Status:  statusPending,
+	}
+}
+
+func saveProposal(ctx context.Context, store CatalogStore, p *proposal) error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return store.WriteObject(ctx, path.Join(proposalsPrefix, p.ID+".json"), data)
+}
+
+// archiveProposal moves a proposal that is no longer pending out of the
+// pending proposals.
+func archiveProposal(ctx context.Context, store CatalogStore, p *proposal) error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	if err := store.WriteObject(ctx, path.Join(decidedProposalsPrefix, p.ID+".json"), data); err != nil {
+		return err
+	}
+	return store.DeleteObject(ctx, path.Join(proposalsPrefix, p.ID+".json"))
+}
+
+// loadProposals reloads all pending proposals from the store. Records that
+// can't be parsed are logged and skipped, and decided proposals left over
+// from before they were archived are moved out of the way.
+func loadProposals(ctx context.Context, store CatalogStore) error {
+	keys, err := store.ListObjects(ctx, proposalsPrefix)
+	if err != nil {
+		return err
+	}
+	for _, k := range keys {
+		data, err := store.ReadObject(ctx, k)
+		if err != nil {
+			return err
+		}
+		var p proposal
+		if err := json.Unmarshal(data, &p); err != nil {
+			log.Printf("Error parsing proposal %q, skipping it: %v", k, err)
+			continue
+		}
+		if p.Status != statusPending {
+			if err := archiveProposal(ctx, store, &p); err != nil {
+				log.Printf("Error archiving proposal %q: %v", k, err)
+			}
+			continue
+		}
+		queueFor(p.Kind).load(&p)
+	}
+	return nil
+}
+
+// queueFor returns the approval queue for a kind of proposal.
+func queueFor(kind string) *waitingApproval {
+	switch kind {
+	case proposalVariation:
+		return &waitingVariations
+	case proposalEdit:
+		return &waitingEdits
+	}
+	return &waitingCreates
+}
+
+// findPending returns the pending proposal with the given ID from any queue.
+func findPending(id string) (*proposal, bool) {
+	for _, a := range []*waitingApproval{&waitingCreates, &waitingVariations, &waitingEdits} {
+		if p, ok := a.byID(id); ok {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// waitingApproval tracks the pending proposals of one kind by key. The
+// proposals in it are never modified, callers get copies and changes are made
+// by swapping in a new copy, so the lock is never held while the store is
+// written.
+type waitingApproval struct {
+	kind    string
+	pending map[string]*proposal
+	sync.Mutex
+}
+
+// copyProposal returns a copy of p that can be changed without affecting the
+// tracked proposal.
+func copyProposal(p *proposal) *proposal {
+	cp := *p
+	cp.ApproverMessages = append([]messageRef(nil), p.ApproverMessages...)
+	return &cp
+}
+
+func (a *waitingApproval) list() (ret []*proposal) {
+	a.Lock()
+	defer a.Unlock()
+	for _, v := range a.pending {
+		ret = append(ret, copyProposal(v))
+	}
+	return
+}
+
+func (a *waitingApproval) get(k string) (*proposal, bool) {
+	a.Lock()
+	defer a.Unlock()
+	v, ok := a.pending[k]
+	if !ok {
+		return nil, false
+	}
+	return copyProposal(v), true
+}
+
+// byID returns the pending proposal with the given ID.
+func (a *waitingApproval) byID(id string) (*proposal, bool) {
+	a.Lock()
+	defer a.Unlock()
+	for _, p := range a.pending {
+		if p.ID == id {
+			return copyProposal(p), true
+		}
+	}
+	return nil, false
+}
+
+// track records the approver DMs sent about a pending proposal.
+func (a *waitingApproval) track(ctx context.Context, store CatalogStore, p *proposal, refs []messageRef) error {
+	a.Lock()
+	cur, ok := a.pending[p.Key]
+	if !ok || cur.ID != p.ID {
+		a.Unlock()
+		return fmt.Errorf("%s proposal %q is no longer pending", a.kind, p.ID)
+	}
+	updated := copyProposal(cur)
+	updated.ApproverMessages = append(updated.ApproverMessages, refs...)
+	a.pending[p.Key] = updated
+	a.Unlock()
+
+	if err := saveProposal(ctx, store, updated); err != nil {
+		return err
+	}
+	// If it was decided while saving, the save put back the pending record
+	// the decision removed.
+	if _, ok := a.byID(p.ID); !ok {
+		return store.DeleteObject(ctx, path.Join(proposalsPrefix, p.ID+".json"))
+	}
+	return nil
+}
+
+// load tracks an already persisted proposal, keeping the newest if there are
+// duplicates for the same key.
+func (a *waitingApproval) load(p *proposal) {
+	a.Lock()
+	defer a.Unlock()
+	if cur, ok := a.pending[p.Key]; ok && cur.Created.After(p.Created) {
+		return
+	}
+	a.pending[p.Key] = p
+}
+
+// add persists and tracks a new proposal. Submitters can replace their own
+// pending proposal for the same key, which is marked as replaced and returned
+// so its review can be closed. If someone else's proposal is pending it
+// returns errAlreadyPending.
+func (a *waitingApproval) add(ctx context.Context, store CatalogStore, p *proposal) (*proposal, error) {
+	a.Lock()
+	old, ok := a.pending[p.Key]
+	if ok && old.SubmitterID != p.SubmitterID {
+		a.Unlock()
+		return nil, errAlreadyPending
+	}
+	a.pending[p.Key] = copyProposal(p)
+	a.Unlock()
+
+	if err := saveProposal(ctx, store, p); err != nil {
+		a.Lock()
+		if cur, ok := a.pending[p.Key]; ok && cur.ID == p.ID {
+			delete(a.pending, p.Key)
+			if old != nil {
+				a.pending[p.Key] = old
+			}
+		}
+		a.Unlock()
+		return nil, err
+	}
+	if old == nil {
+		return nil, nil
+	}
+	replaced := copyProposal(old)
+	replaced.Status = statusReplaced
+	replaced.Decided = time.Now().UTC()
+	return replaced, archiveProposal(ctx, store, replaced)
+}
+
+// claim stops tracking the pending proposal with the given ID so no one else
+// can decide on it, returning false if it was already decided or replaced.
+func (a *waitingApproval) claim(p *proposal) bool {
+	a.Lock()
+	defer a.Unlock()
+	cur, ok := a.pending[p.Key]
+	if !ok || cur.ID != p.ID {
+		return false
+	}
+	delete(a.pending, p.Key)
+	return true
+}
+
+// unclaim tracks a claimed proposal again after deciding on it failed,
+// unless it was replaced in the meantime.
+func (a *waitingApproval) unclaim(p *proposal) {
+	a.Lock()
+	defer a.Unlock()
+	if _, ok := a.pending[p.Key]; !ok {
+		a.pending[p.Key] = p
+	}
+}
+
+// resolve records a decision on a claimed proposal, p is only updated once
+// the decision is saved.
+func resolve(ctx context.Context, store CatalogStore, p *proposal, status, decidedBy, reason string) error {
+	d := *p
+	d.Status = status
+	d.DecidedBy = decidedBy
+	d.Reason = reason
+	d.Decided = time.Now().UTC()
+	if err := archiveProposal(ctx, store, &d); err != nil {
+		return err
+	}
+	*p = d
+	return nil
+}
+
+// reopen puts a claimed proposal back up for review after publishing it
+// failed.
+func reopen(ctx context.Context, store CatalogStore, p *proposal) {
+	p.Status = statusPending
+	p.DecidedBy = ""
+	p.Reason = ""
+	p.Decided = time.Time{}
+	if err := saveProposal(ctx, store, p); err != nil {
+		log.Printf("Error reopening proposal %q: %v", p.ID, err)
+		return
+	}
+	if err := store.DeleteObject(ctx, path.Join(decidedProposalsPrefix, p.ID+".json")); err != nil {
+		log.Printf("Error removing decision on proposal %q: %v", p.ID, err)
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	maxStars = 5
+	// topMinVotes is how many ratings a cocktail needs to make /cocktail top,
+	// so a single five star rating can't top the board.
+	topMinVotes = 3
+)
+
+var minStars = 1.0
+
+// cocktailRatings are the stars each user gave a cocktail, keyed by user ID
+// and stored at <name>/ratings.json.
+type cocktailRatings map[string]int
+
+func ratingsKey(cocktail string) string {
+	return path.Join(cocktail, "ratings.json")
+}
+
+// average returns the mean rating and how many ratings there are.
+func (r cocktailRatings) average() (float64, int) {
+	if len(r) == 0 {
+		return 0, 0
+	}
+	var total int
+	for _, stars := range r {
+		total += stars
+	}
+	return float64(total) / float64(len(r)), len(r)
+}
+
+// ratingBook is an in memory copy of every cocktail's ratings, so averages
+// can be shown without reading the store.
+type ratingBook struct {
+	sync.RWMutex
+	ratings map[string]cocktailRatings
+}
+
+var ratings = ratingBook{ratings: map[string]cocktailRatings{}}
+
+// load reads the ratings of every cocktail from the store.
+func (b *ratingBook) load(ctx context.Context, store CatalogStore) error {
+	cocktails, err := listCocktails(ctx, store)
+	if err != nil {
+		return err
+	}
+	fresh := map[string]cocktailRatings{}
+	for _, cocktail := range cocktails {
+		data, err := store.ReadObject(ctx, ratingsKey(cocktail))
+		if err == errNotFound {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		var r cocktailRatings
+		if err := json.Unmarshal(data, &r); err != nil {
+			log.Printf("Error parsing ratings for %q: %v", cocktail, err)
+			continue
+		}
+		fresh[cocktail] = r
+	}
+
+	b.Lock()
+	defer b.Unlock()
+	b.ratings = fresh
+	return nil
+}
+
+// rate records a user's rating of a cocktail, replacing any earlier one.
+func (b *ratingBook) rate(ctx context.Context, store CatalogStore, cocktail, userID string, stars int) error {
+	b.Lock()
+	defer b.Unlock()
+	r := cocktailRatings{}
+	for id, n := range b.ratings[cocktail] {
+		r[id] = n
+	}
+	r[userID] = stars
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if err := store.WriteObject(ctx, ratingsKey(cocktail), data); err != nil {
+		return err
+	}
+	b.ratings[cocktail] = r
+	return nil
+}
+
+// average returns the mean rating of a cocktail and how many ratings it has.
+func (b *ratingBook) average(cocktail string) (float64, int) {
+	b.RLock()
+	defer b.RUnlock()
+	return b.ratings[cocktail].average()
+}
+
+// ratingSummary describes a cocktail's rating for display.
+func ratingSummary(cocktail string) string {
+	avg, n := ratings.average(cocktail)
+	switch n {
+	case 0:
+		return "Not rated yet, use /cocktail rate"
+	case 1:
+		return fmt.Sprintf("%.1f/%d from 1 rating", avg, maxStars)
+	}
+	return fmt.Sprintf("%.1f/%d from %d ratings", avg, maxStars, n)
+}
+
+// rate records the user's rating of a cocktail.
+func rate(ctx context.Context, store CatalogStore, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	opts := subcommandOptions(i)
+	name := opts["name"].StringValue()
+	stars := int(opts["stars"].IntValue())
+	if stars < int(minStars) || stars > maxStars {
+		respond(s, i.Interaction, fmt.Sprintf("Ratings are from %d to %d stars", int(minStars), maxStars), nil, true)
+		return
+	}
+
+	cocktails, err := listCocktails(ctx, store)
+	if err != nil {
+		logInteractionError(s, i.Interaction, err)
+		return
+	}
+	found, suggestions := findCocktail(name, cocktails, catalog.aliasNames())
+	if found == "" {
+		respond(s, i.Interaction, didYouMean(name, suggestions), nil, true)
+		return
+	}
+
+	if err := ratings.rate(ctx, store, found, interactionUser(i).ID, stars); err != nil {
+		logInteractionError(s, i.Interaction, err)
+		return
+	}
+	respond(s, i.Interaction, fmt.Sprintf("Rated %s %s, now %s", found, strings.Repeat("★", stars), ratingSummary(found)), nil, true)
+}
+
+// top lists the highest rated cocktails with at least topMinVotes ratings,
+// optionally only those with a tag.
+func top(ctx context.Context, store CatalogStore, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	var tag string
+	if opt, ok := subcommandOptions(i)["tag"]; ok {
+		tag = normalizeTag(opt.StringValue())
+	}
+
+	type ranked struct {
+		cocktail string
+		avg      float64
+		n        int
+	}
+	var board []ranked
+	for _, cocktail := range catalog.names() {
+		if tag != "" {
+			if sp, ok := catalog.spec(cocktail); !ok || !sp.hasTag(tag) {
+				continue
+			}
+		}
+		if avg, n := ratings.average(cocktail); n >= topMinVotes {
+			board = append(board, ranked{cocktail, avg, n})
+		}
+	}
+	if len(board) == 0 {
+		respond(s, i.Interaction, fmt.Sprintf("No cocktails have %d or more ratings yet", topMinVotes), nil, true)
+		return
+	}
+	sort.SliceStable(board, func(a, b int) bool {
+		if board[a].avg != board[b].avg {
+			return board[a].avg > board[b].avg
+		}
+		return board[a].n > board[b].n
+	})
+
+	var items []string
+	for n, r := range board {
+		items = append(items, fmt.Sprintf("%d. %s, %.1f/%d from %d ratings", n+1, r.cocktail, r.avg, maxStars, r.n))
+	}
+	header := "Top rated cocktails:\n"
+	if tag != "" {
+		header = fmt.Sprintf("Top rated cocktails tagged %s:\n", tag)
+	}
+	respondPages(s, i.Interaction, header, items, false)
+}
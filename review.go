@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// reviewButtonID prefixes the custom IDs of the buttons on approver DMs,
+	// which look like review:<action>:<proposal ID>.
+	reviewButtonID = "review"
+	reviewApprove  = "approve"
+	reviewDeny     = "deny"
+	reviewChanges  = "changes"
+)
+
+// reviewComponents are the buttons attached to the approver DM for a
+// proposal.
+func reviewComponents(p *proposal) []discordgo.MessageComponent {
+	button := func(label string, style discordgo.ButtonStyle, action string) discordgo.MessageComponent {
+		return discordgo.Button{
+			Label:    label,
+			Style:    style,
+			CustomID: reviewButtonID + ":" + action + ":" + p.ID,
+		}
+	}
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			button("Approve", discordgo.SuccessButton, reviewApprove),
+			button("Deny", discordgo.DangerButton, reviewDeny),
+			button("Request changes", discordgo.SecondaryButton, reviewChanges),
+		}},
+	}
+}
+
+// publish writes an approved proposal to the catalog and returns the name it
+// was written under. Variations are appended to the current spec and edits
+// replace it, the previous spec is kept in the cocktail's history.
+func publish(ctx context.Context, store CatalogStore, p *proposal, approverID string) (string, error) {
+	name, sp := p.Key, p.Spec
+	switch p.Kind {
+	case proposalEdit:
+		name = p.Target
+	case proposalVariation:
+		cur, err := getSpec(ctx, store, p.Spec.Name)
+		if err != nil {
+			return "", err
+		}
+		// We just take the ingredients from the proposed variation.
+		cur.Ingredients = append(cur.Ingredients, p.Spec.Ingredients...)
+		name, sp = p.Spec.Name, cur
+	}
+
+	v := &specVersion{
+		AuthorID:   p.SubmitterID,
+		Author:     p.Submitter,
+		ApproverID: approverID,
+		GuildID:    p.GuildID,
+		Spec:       sp,
+	}
+	if err := saveSpec(ctx, store, name, v); err != nil {
+		return "", err
+	}
+	catalog.update(name, sp)
+	return name, nil
+}
+
+// decide records an approver's decision on a pending proposal, publishing it
+// if approved, and tells the approvers and the submitter the outcome. It
+// returns the cocktail name the proposal was published under, if any, or
+// errAlreadyDecided if the proposal is no longer pending.
+func decide(ctx context.Context, store CatalogStore, s *discordgo.Session, p *proposal, status, approverID, reason string) (string, error) {
+	// Claim the proposal first so a double click, or a button racing the
+	// slash command, can't publish it twice.
+	a := queueFor(p.Kind)
+	if !a.claim(p) {
+		return "", errAlreadyDecided
+	}
+	// Record the decision before publishing, a proposal that is in the
+	// catalog must never be left pending to be published again.
+	if err := resolve(ctx, store, p, status, approverID, reason); err != nil {
+		a.unclaim(p)
+		return "", err
+	}
+	var name string
+	if status == statusApproved {
+		var err error
+		if name, err = publish(ctx, store, p, approverID); err != nil {
+			reopen(ctx, store, p)
+			a.unclaim(p)
+			return "", err
+		}
+	}
+	closeReview(s, p)
+	notifySubmitter(ctx, store, s, p)
+	return name, nil
+}
+
+// decisionText describes a decided proposal for the approver DMs.
+func decisionText(p *proposal) string {
+	verb := p.Status
+	switch p.Status {
+	case statusApproved:
+		verb = "Approved"
+	case statusDenied:
+		verb = "Denied"
+	case statusChangesRequested:
+		verb = "Changes requested"
+	case statusReplaced:
+		return fmt.Sprintf("**Replaced** by a newer submission on %s", p.Decided.Format(time.RFC822))
+	}
+	text := fmt.Sprintf("**%s** by <@%s> on %s", verb, p.DecidedBy, p.Decided.Format(time.RFC822))
+	if p.Reason != "" {
+		text += "\nReason: " + p.Reason
+	}
+	return text
+}
+
+// notifySubmitter tells the submitter of a decided proposal the outcome,
+// either by DM or by mentioning them where they submitted it, depending on
+// the guild's configuration.
+func notifySubmitter(ctx context.Context, store CatalogStore, s *discordgo.Session, p *proposal) {
+	var content string
+	switch p.Status {
+	case statusApproved:
+		content = fmt.Sprintf("Your proposal for %q was approved, thanks!", p.Spec.Name)
+	case statusDenied:
+		content = fmt.Sprintf("Your proposal for %q was denied.", p.Spec.Name)
+	case statusChangesRequested:
+		content = fmt.Sprintf("An approver asked for changes to your proposal for %q, please submit it again.", p.Spec.Name)
+	default:
+		return
+	}
+	if p.Reason != "" {
+		content += "\nReason: " + p.Reason
+	}
+
+	cfg, err := getGuildConfig(ctx, store, p.GuildID)
+	if err != nil {
+		log.Printf("Error reading config for guild %q: %v", p.GuildID, err)
+	} else if cfg.Notifications == notifyChannel && p.ChannelID != "" {
+		_, err := s.ChannelMessageSend(p.ChannelID, fmt.Sprintf("<@%s> %s", p.SubmitterID, content))
+		if err == nil {
+			return
+		}
+		// Fall back to a DM, the bot may have lost access to the channel.
+		log.Printf("Error notifying submitter of proposal %q in channel: %v", p.ID, err)
+	}
+	dm(s, p.SubmitterID, content)
+}
+
+// closeReview edits the approver DMs for a decided proposal to show the
+// decision, removing the buttons.
+func closeReview(s *discordgo.Session, p *proposal) {
+	for _, ref := range p.ApproverMessages {
+		m, err := s.ChannelMessage(ref.ChannelID, ref.MessageID)
+		if err != nil {
+			log.Printf("Error fetching approver message for proposal %q: %v", p.ID, err)
+			continue
+		}
+		content := m.Content + "\n\n" + decisionText(p)
+		if _, err := s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+			ID:         ref.MessageID,
+			Channel:    ref.ChannelID,
+			Content:    &content,
+			Components: []discordgo.MessageComponent{},
+		}); err != nil {
+			log.Printf("Error updating approver message for proposal %q: %v", p.ID, err)
+		}
+	}
+}
+
+// reviewButton handles the buttons on approver DMs.
+func reviewButton(ctx context.Context, store CatalogStore, s *discordgo.Session, i *discordgo.InteractionCreate, action, id string) {
+	p, ok := findPending(id)
+	if !ok {
+		respond(s, i.Interaction, "This proposal has already been decided or replaced.", nil, true)
+		return
+	}
+
+	// Buttons are pressed in DMs, so check against the guild the proposal
+	// came from.
+	user := interactionUser(i)
+	approver, err := isApprover(ctx, store, p.GuildID, user.ID, memberRoles(s, p.GuildID, user.ID))
+	if err != nil {
+		logInteractionError(s, i.Interaction, err)
+		return
+	}
+	if !approver {
+		respond(s, i.Interaction, "You're not my boss!", nil, true)
+		return
+	}
+
+	var status string
+	switch action {
+	case reviewApprove:
+		status = statusApproved
+	case reviewDeny:
+		status = statusDenied
+	case reviewChanges:
+		status = statusChangesRequested
+	default:
+		return
+	}
+
+	// Acknowledge first, publishing can take longer than Discord waits for a
+	// response. The message itself is edited by decide.
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	}); err != nil {
+		logInteractionError(s, i.Interaction, err)
+		return
+	}
+	// If someone else got there first the message is updated with their
+	// decision, so there is nothing more to say.
+	if _, err := decide(ctx, store, s, p, status, user.ID, ""); err != nil && err != errAlreadyDecided {
+		logInteractionError(s, i.Interaction, err)
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// How a drink is mixed, stored in spec.Method.
+const (
+	mixShake   = "shake"
+	mixStir    = "stir"
+	mixBuild   = "build"
+	mixBlend   = "blend"
+	mixThrow   = "throw"
+	mixSwizzle = "swizzle"
+)
+
+var methodChoices = []*discordgo.ApplicationCommandOptionChoice{
+	{Name: "shaken", Value: mixShake},
+	{Name: "stirred", Value: mixStir},
+	{Name: "built in the glass", Value: mixBuild},
+	{Name: "blended", Value: mixBlend},
+	{Name: "thrown", Value: mixThrow},
+	{Name: "swizzled", Value: mixSwizzle},
+}
+
+// What a drink is served over, stored in spec.Ice.
+var iceChoices = []*discordgo.ApplicationCommandOptionChoice{
+	{Name: "no ice, served up", Value: "none"},
+	{Name: "cubes", Value: "cubes"},
+	{Name: "large cube", Value: "large cube"},
+	{Name: "crushed", Value: "crushed"},
+	{Name: "pebble", Value: "pebble"},
+	{Name: "cracked", Value: "cracked"},
+}
+
+// glasses are suggested for spec.Glass, other glasses can be typed in.
+var glasses = []string{
+	"coupe",
+	"nick & nora",
+	"martini",
+	"rocks",
+	"double rocks",
+	"highball",
+	"collins",
+	"julep cup",
+	"tiki mug",
+	"hurricane",
+	"copper mug",
+	"flute",
+	"wine glass",
+	"snifter",
+	"shot glass",
+}
+
+// normalizeGlass puts a glass name in its stored form.
+func normalizeGlass(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
+
+// knownGlasses returns the suggested glasses followed by any others used in
+// the catalog.
+func knownGlasses() []string {
+	names := append([]string(nil), glasses...)
+	for _, cocktail := range catalog.names() {
+		if sp, ok := catalog.spec(cocktail); ok && sp.Glass != "" {
+			names, _ = addUnique(names, sp.Glass)
+		}
+	}
+	return names
+}
+
+// servingOptions are the glass, method and ice command options, either for
+// describing a spec or for filtering the catalog.
+func servingOptions(filter bool) []*discordgo.ApplicationCommandOption {
+	glass, method, ice := "glass it is served in", "how it is mixed", "ice it is served over"
+	if filter {
+		glass, method, ice = "only cocktails served in this glass", "only cocktails mixed this way", "only cocktails served over this ice"
+	}
+	return []*discordgo.ApplicationCommandOption{
+		{
+			Type:         discordgo.ApplicationCommandOptionString,
+			Name:         "glass",
+			Description:  glass,
+			Required:     false,
+			Autocomplete: true,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "method",
+			Description: method,
+			Required:    false,
+			Choices:     methodChoices,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "ice",
+			Description: ice,
+			Required:    false,
+			Choices:     iceChoices,
+		},
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeGlass(t *testing.T) {
+	for _, tc := range []struct {
+		glass string
+		want  string
+	}{
+		{"coupe", "coupe"},
+		{"Nick & Nora", "nick & nora"},
+		{"  Double   Rocks ", "double rocks"},
+		{"", ""},
+	} {
+		if got := normalizeGlass(tc.glass); got != tc.want {
+			t.Errorf("normalizeGlass(%q) = %q, want %q", tc.glass, got, tc.want)
+		}
+	}
+}
+
+func TestRenderServing(t *testing.T) {
+	for _, tc := range []struct {
+		sp   spec
+		want string
+	}{
+		{spec{Garnish: "lime wheel"}, "Garnish: lime wheel\n\nInstructions:"},
+		{spec{Garnish: "lime wheel", Glass: "coupe", Method: mixShake, Ice: "none"}, "Garnish: lime wheel\nGlass: coupe\nMethod: shake\nIce: none\n\nInstructions:"},
+		{spec{Garnish: "mint", Ice: "crushed"}, "Garnish: mint\nIce: crushed\n\nInstructions:"},
+	} {
+		if got := tc.sp.String(); !strings.Contains(got, tc.want) {
+			t.Errorf("%+v.String() = %q, want it to contain %q", tc.sp, got, tc.want)
+		}
+	}
+}
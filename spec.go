@@ -7,30 +7,59 @@ import (
 )
 
 type spec struct {
-	Name        string
+	Name string
+	// Aliases are other names the cocktail goes by.
+	Aliases     []string `json:",omitempty"`
 	Ingredients []variation
 	Garnish     string
+	// Glass, Method and Ice describe how the drink is served, Method is one
+	// of the mix constants.
+	Glass  string `json:",omitempty"`
+	Method string `json:",omitempty"`
+	Ice    string `json:",omitempty"`
 	// list of instructions
 	Instructions []string
+	// Tags categorise the cocktail, such as sour, tiki or a base spirit.
+	Tags []string `json:",omitempty"`
+	// Creator, Source, Year and Notes record where the drink came from,
+	// Source is a book, bar or URL.
+	Creator string `json:",omitempty"`
+	Source  string `json:",omitempty"`
+	Year    int    `json:",omitempty"`
+	Notes   string `json:",omitempty"`
 }
 
 // list of ingredients in this variation
-type variation []string
+type variation []ingredient
 
 var (
 	namePrefix         = "Name: "
+	aliasesPrefix      = "Also known as: "
 	ingredientsPrefix  = "Ingredients:"
 	garnishPrefix      = "Garnish: "
 	instructionsPrefix = "Instructions:"
+	tagsPrefix         = "Tags: "
+	glassPrefix        = "Glass: "
+	methodPrefix       = "Method: "
+	icePrefix          = "Ice: "
+	creatorPrefix      = "Created by: "
+	yearPrefix         = "Year: "
+	sourcePrefix       = "Source: "
+	notesPrefix        = "Notes: "
 )
 
 func (s *spec) String() string {
+	return s.render(unitsOriginal)
+}
+
+// render formats the spec with pours converted to the given unit system.
+func (s *spec) render(system unitSystem) string {
 	var ingredients string
 	var instructions string
 	for i, v := range s.Ingredients {
 		var newVar string
 		for _, ing := range v {
-			newVar = fmt.Sprintf("%s%s\n", newVar, strings.TrimSpace(ing))
+			newVar = fmt.Sprintf("%s%s\n", newVar, ing.in(system))
 		}
 		if len(s.Ingredients) == 1 {
 			ingredients = newVar
@@ -43,8 +72,28 @@ func (s *spec) String() string {
 		instructions = fmt.Sprintf("%s%s\n", instructions, strings.TrimSpace(i))
 	}
 	ingredients = strings.TrimSpace(ingredients)
+	var aliases string
+	if len(s.Aliases) > 0 {
+		aliases = fmt.Sprintf("\n%s%s", aliasesPrefix, strings.Join(s.Aliases, ", "))
+	}
+	var serving string
+	for _, f := range [][2]string{{glassPrefix, s.Glass}, {methodPrefix, s.Method}, {icePrefix, s.Ice}} {
+		if f[1] != "" {
+			serving = fmt.Sprintf("%s\n%s%s", serving, f[0], f[1])
+		}
+	}
+	var about string
+	for _, f := range [][2]string{{creatorPrefix, s.Creator}, {yearPrefix, s.year()}, {sourcePrefix, s.Source}, {notesPrefix, s.Notes}} {
+		if f[1] != "" {
+			about = fmt.Sprintf("%s\n%s%s", about, f[0], f[1])
+		}
+	}
+	var tags string
+	if len(s.Tags) > 0 {
+		tags = fmt.Sprintf("\n%s%s\n", tagsPrefix, strings.Join(s.Tags, ", "))
+	}
 	return fmt.Sprintf(
-		"%s%s\n\n%s\n%s\n\n%s%s\n\n%s\n%s", namePrefix, s.Name, ingredientsPrefix, ingredients, garnishPrefix, s.Garnish, instructionsPrefix, instructions)
+		"%s%s%s\n\n%s\n%s\n\n%s%s%s\n\n%s\n%s%s%s", namePrefix, s.Name, aliases, ingredientsPrefix, ingredients, garnishPrefix, s.Garnish, serving, instructionsPrefix, instructions, about, tags)
 }
 
 func parseSpec(data []byte) (*spec, error) {
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// metaPrefix is the reserved top level prefix for bot data that isn't part of
+// the catalog itself, it is never listed as a cocktail.
+const metaPrefix = "_meta"
+
+// errNotFound is returned by ReadObject when the object does not exist.
+var errNotFound = errors.New("object not found")
+
+// CatalogStore is the storage backend for the cocktail catalog.
+//
+// Every cocktail lives under its own prefix, with the spec stored at
+// <name>/spec and pictures stored at <name>/pictures/<file>. Other bot data is
+// stored as plain objects, keyed by slash separated paths.
+type CatalogStore interface {
+	// ListCocktails returns the names of all cocktails in the catalog.
+	ListCocktails(ctx context.Context) ([]string, error)
+	// ReadSpec returns the raw spec for the named cocktail.
+	ReadSpec(ctx context.Context, name string) ([]byte, error)
+	// WriteSpec creates or overwrites the spec for the named cocktail.
+	WriteSpec(ctx context.Context, name string, data []byte) error
+	// ListPictures returns the file names of all pictures for the named cocktail.
+	ListPictures(ctx context.Context, name string) ([]string, error)
+	// ReadPicture opens a picture for the named cocktail, the caller must
+	// close the returned reader.
+	ReadPicture(ctx context.Context, name, file string) (io.ReadCloser, string, error)
+	// WritePicture creates or overwrites a picture for the named cocktail.
+	WritePicture(ctx context.Context, name, file string, r io.Reader) error
+
+	// ReadObject returns the contents of the object at key, or errNotFound.
+	ReadObject(ctx context.Context, key string) ([]byte, error)
+	// WriteObject creates or overwrites the object at key.
+	WriteObject(ctx context.Context, key string, data []byte) error
+	// ListObjects returns the keys of all objects under prefix.
+	ListObjects(ctx context.Context, prefix string) ([]string, error)
+	// DeleteObject removes the object at key, it is not an error if the
+	// object does not exist.
+	DeleteObject(ctx context.Context, key string) error
+}
+
+func newStore(ctx context.Context, kind string) (CatalogStore, error) {
+	switch kind {
+	case "gcs":
+		return newGCSStore(ctx, *bucket)
+	case "fs":
+		return newFSStore(*dir)
+	}
+	return nil, fmt.Errorf("unknown store type %q", kind)
+}
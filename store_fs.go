@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// fsStore is a CatalogStore backed by a local directory, using the same
+// layout as the GCS bucket so the two can be synced back and forth.
+type fsStore struct {
+	root string
+}
+
+// path returns where a key made up of the given slash separated parts lives
+// under the root. Names come from users, so parts that would reach outside the
+// root, or into another part, are rejected.
+func (f *fsStore) path(parts ...string) (string, error) {
+	elems := []string{f.root}
+	for _, part := range parts {
+		for _, seg := range strings.Split(part, "/") {
+			if seg == "" || seg == "." || seg == ".." || strings.ContainsAny(seg, `\`+string(filepath.Separator)) {
+				return "", fmt.Errorf("invalid name %q", part)
+			}
+			elems = append(elems, seg)
+		}
+	}
+	return filepath.Join(elems...), nil
+}
+
+func newFSStore(root string) (*fsStore, error) {
+	if root == "" {
+		return nil, fmt.Errorf("-dir must be set when using the fs store")
+	}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, err
+	}
+	return &fsStore{root: root}, nil
+}
+
+func (f *fsStore) ListCocktails(ctx context.Context) ([]string, error) {
+	entries, err := ioutil.ReadDir(f.root)
+	if err != nil {
+		return nil, err
+	}
+	var cocktails []string
+	for _, e := range entries {
+		if e.IsDir() && !strings.HasPrefix(e.Name(), ".") && e.Name() != metaPrefix {
+			cocktails = append(cocktails, e.Name())
+		}
+	}
+	return cocktails, nil
+}
+
+func (f *fsStore) ReadSpec(ctx context.Context, name string) ([]byte, error) {
+	p, err := f.path(name, "spec")
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadFile(p)
+}
+
+func (f *fsStore) WriteSpec(ctx context.Context, name string, data []byte) error {
+	p, err := f.path(name, "spec")
+	if err != nil {
+		return err
+	}
+	return f.write(p, bytes.NewReader(data))
+}
+
+func (f *fsStore) ListPictures(ctx context.Context, name string) ([]string, error) {
+	p, err := f.path(name, "pictures")
+	if err != nil {
+		return nil, err
+	}
+	entries, err := ioutil.ReadDir(p)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var pics []string
+	for _, e := range entries {
+		// Skip in-progress writes, see write.
+		if !e.IsDir() && !strings.HasPrefix(e.Name(), ".") {
+			pics = append(pics, e.Name())
+		}
+	}
+	return pics, nil
+}
+
+func (f *fsStore) ReadPicture(ctx context.Context, name, file string) (io.ReadCloser, string, error) {
+	p, err := f.path(name, "pictures", file)
+	if err != nil {
+		return nil, "", err
+	}
+	reader, err := os.Open(p)
+	if err != nil {
+		return nil, "", err
+	}
+	return reader, mime.TypeByExtension(filepath.Ext(file)), nil
+}
+
+func (f *fsStore) WritePicture(ctx context.Context, name, file string, r io.Reader) error {
+	p, err := f.path(name, "pictures", file)
+	if err != nil {
+		return err
+	}
+	return f.write(p, r)
+}
+
+func (f *fsStore) ReadObject(ctx context.Context, key string) ([]byte, error) {
+	p, err := f.path(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadFile(p)
+	if os.IsNotExist(err) {
+		return nil, errNotFound
+	}
+	return data, err
+}
+
+func (f *fsStore) WriteObject(ctx context.Context, key string, data []byte) error {
+	p, err := f.path(key)
+	if err != nil {
+		return err
+	}
+	return f.write(p, bytes.NewReader(data))
+}
+
+func (f *fsStore) ListObjects(ctx context.Context, prefix string) ([]string, error) {
+	dir, err := f.path(strings.TrimSuffix(prefix, "/"))
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	err = filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || strings.HasPrefix(info.Name(), ".") {
+			return nil
+		}
+		rel, err := filepath.Rel(f.root, p)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, filepath.ToSlash(rel))
+		return nil
+	})
+	return keys, err
+}
+
+func (f *fsStore) DeleteObject(ctx context.Context, key string) error {
+	p, err := f.path(key)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(p)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// write writes to a temporary file and renames it into place so readers never
+// see a partially written object.
+func (f *fsStore) write(name string, r io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(name), "."+filepath.Base(name))
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestFSStorePath(t *testing.T) {
+	f := &fsStore{root: "/catalog"}
+	for _, tc := range []struct {
+		parts []string
+		want  string
+	}{
+		{[]string{"Negroni", "spec"}, "/catalog/Negroni/spec"},
+		{[]string{"_meta/users/1/bar"}, "/catalog/_meta/users/1/bar"},
+		{[]string{"Negroni", "pictures", "negroni.jpg"}, "/catalog/Negroni/pictures/negroni.jpg"},
+		{[]string{"..", "spec"}, ""},
+		{[]string{"Negroni/../..", "spec"}, ""},
+		{[]string{"Negroni", "pictures", "../../x"}, ""},
+		{[]string{`..\x`, "spec"}, ""},
+		{[]string{"", "spec"}, ""},
+		{[]string{"/etc/passwd"}, ""},
+	} {
+		got, err := f.path(tc.parts...)
+		if tc.want == "" {
+			if err == nil {
+				t.Errorf("path(%q) = %q, want an error", tc.parts, got)
+			}
+			continue
+		}
+		if err != nil || got != filepath.FromSlash(tc.want) {
+			t.Errorf("path(%q) = %q, %v, want %q", tc.parts, got, err, tc.want)
+		}
+	}
+}
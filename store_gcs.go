@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
+)
+
+// gcsStore is a CatalogStore backed by a GCS bucket.
+type gcsStore struct {
+	client *storage.Client
+	bucket string
+}
+
+func newGCSStore(ctx context.Context, bucket string) (*gcsStore, error) {
+	if bucket == "" {
+		return nil, fmt.Errorf("-bucket must be set when using the gcs store")
+	}
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &gcsStore{client: client, bucket: bucket}, nil
+}
+
+func (g *gcsStore) ListCocktails(ctx context.Context) ([]string, error) {
+	query := &storage.Query{Delimiter: "/"}
+	query.SetAttrSelection([]string{"Prefix"})
+
+	var cocktails []string
+	it := g.client.Bucket(g.bucket).Objects(ctx, query)
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if attrs.Prefix == "" || attrs.Prefix == metaPrefix+"/" {
+			continue
+		}
+		cocktails = append(cocktails, strings.TrimSuffix(attrs.Prefix, "/"))
+	}
+	return cocktails, nil
+}
+
+func (g *gcsStore) ReadSpec(ctx context.Context, name string) ([]byte, error) {
+	return g.read(ctx, path.Join(name, "spec"))
+}
+
+func (g *gcsStore) WriteSpec(ctx context.Context, name string, data []byte) error {
+	return g.write(ctx, path.Join(name, "spec"), bytes.NewReader(data))
+}
+
+func (g *gcsStore) ListPictures(ctx context.Context, name string) ([]string, error) {
+	prefix := path.Join(name, "pictures") + "/"
+	query := &storage.Query{Prefix: prefix}
+	query.SetAttrSelection([]string{"Name"})
+
+	var pics []string
+	it := g.client.Bucket(g.bucket).Objects(ctx, query)
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		// Skip the "directory" placeholder object.
+		if attrs.Name == prefix {
+			continue
+		}
+		pics = append(pics, strings.TrimPrefix(attrs.Name, prefix))
+	}
+	return pics, nil
+}
+
+func (g *gcsStore) ReadPicture(ctx context.Context, name, file string) (io.ReadCloser, string, error) {
+	reader, err := g.client.Bucket(g.bucket).Object(path.Join(name, "pictures", file)).NewReader(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	return reader, reader.Attrs.ContentType, nil
+}
+
+func (g *gcsStore) WritePicture(ctx context.Context, name, file string, r io.Reader) error {
+	return g.write(ctx, path.Join(name, "pictures", file), r)
+}
+
+func (g *gcsStore) ReadObject(ctx context.Context, key string) ([]byte, error) {
+	data, err := g.read(ctx, key)
+	if err == storage.ErrObjectNotExist {
+		return nil, errNotFound
+	}
+	return data, err
+}
+
+func (g *gcsStore) WriteObject(ctx context.Context, key string, data []byte) error {
+	return g.write(ctx, key, bytes.NewReader(data))
+}
+
+func (g *gcsStore) ListObjects(ctx context.Context, prefix string) ([]string, error) {
+	query := &storage.Query{Prefix: strings.TrimSuffix(prefix, "/") + "/"}
+	query.SetAttrSelection([]string{"Name"})
+
+	var keys []string
+	it := g.client.Bucket(g.bucket).Objects(ctx, query)
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasSuffix(attrs.Name, "/") {
+			continue
+		}
+		keys = append(keys, attrs.Name)
+	}
+	return keys, nil
+}
+
+func (g *gcsStore) DeleteObject(ctx context.Context, key string) error {
+	err := g.client.Bucket(g.bucket).Object(key).Delete(ctx)
+	if err == storage.ErrObjectNotExist {
+		return nil
+	}
+	return err
+}
+
+func (g *gcsStore) read(ctx context.Context, object string) ([]byte, error) {
+	reader, err := g.client.Bucket(g.bucket).Object(object).NewReader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return ioutil.ReadAll(reader)
+}
+
+func (g *gcsStore) write(ctx context.Context, object string, r io.Reader) error {
+	writer := g.client.Bucket(g.bucket).Object(object).NewWriter(ctx)
+	if _, err := io.Copy(writer, r); err != nil {
+		writer.Close()
+		return err
+	}
+	return writer.Close()
+}
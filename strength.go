@@ -0,0 +1,302 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// strengths maps ingredient keywords to their typical ABV percentage. Entries
+// are matched as whole words in order, so more specific names come first.
+var strengths = []struct {
+	keyword string
+	abv     float64
+}{
+	// Things that look boozy but aren't.
+	{"ginger beer", 0},
+	{"ginger ale", 0},
+	{"root beer", 0},
+	{"non-alcoholic", 0},
+	{"syrup", 0},
+	{"juice", 0},
+
+	{"green chartreuse", 55},
+	{"yellow chartreuse", 40},
+	{"chartreuse", 55},
+	{"absinthe", 60},
+	{"navy strength", 57},
+	{"overproof", 63},
+	{"bonded", 50},
+	{"cask strength", 60},
+	{"bourbon", 45},
+	{"rye", 45},
+	{"scotch", 43},
+	{"whiskey", 43},
+	{"whisky", 43},
+	{"mezcal", 42},
+	{"tequila", 40},
+	{"gin", 42},
+	{"vodka", 40},
+	{"rum", 40},
+	{"cachaça", 40},
+	{"cachaca", 40},
+	{"cognac", 40},
+	{"armagnac", 40},
+	{"calvados", 40},
+	{"applejack", 40},
+	{"pisco", 40},
+	{"brandy", 40},
+	{"aquavit", 42},
+	{"akvavit", 42},
+
+	{"campari", 24},
+	{"aperol", 11},
+	{"fernet", 39},
+	{"cynar", 16.5},
+	{"amaro", 28},
+	{"maraschino", 32},
+	{"cointreau", 40},
+	{"grand marnier", 40},
+	{"triple sec", 30},
+	{"curaçao", 40},
+	{"curacao", 40},
+	{"benedictine", 40},
+	{"bénédictine", 40},
+	{"drambuie", 40},
+	{"allspice dram", 24},
+	{"falernum", 11},
+	{"st-germain", 20},
+	{"elderflower liqueur", 20},
+	{"amaretto", 28},
+	{"coffee liqueur", 20},
+	{"kahlua", 20},
+	{"crème de", 20},
+	{"creme de", 20},
+	{"liqueur", 25},
+	{"bitters", 45},
+
+	{"dry vermouth", 17},
+	{"blanc vermouth", 16},
+	{"sweet vermouth", 16},
+	{"vermouth", 16},
+	{"lillet", 17},
+	{"cocchi", 16.5},
+	{"sherry", 17},
+	{"port", 20},
+	{"madeira", 19},
+	{"champagne", 12},
+	{"prosecco", 11},
+	{"cava", 11.5},
+	{"sparkling wine", 12},
+	{"wine", 12},
+	{"sake", 15},
+	{"cider", 5},
+	{"beer", 5},
+	{"lager", 5},
+	{"stout", 6},
+}
+
+var nonWord = regexp.MustCompile(`[^\p{L}\p{N}-]+`)
+
+// ingredientABV returns the estimated ABV percentage of an ingredient name,
+// anything not in the strength table is assumed to be non alcoholic.
+func ingredientABV(name string) float64 {
+	words := " " + nonWord.ReplaceAllString(strings.ToLower(name), " ") + " "
+	for _, s := range strengths {
+		if strings.Contains(words, " "+s.keyword+" ") {
+			return s.abv
+		}
+	}
+	return 0
+}
+
+const (
+	methodShaken  = "shaken"
+	methodStirred = "stirred"
+	methodBuilt   = "built"
+)
+
+// specMethod returns how a spec is mixed for estimating dilution, guessing
+// from its instructions if it doesn't say.
+func specMethod(sp *spec) string {
+	switch sp.Method {
+	case mixShake, mixBlend, mixSwizzle:
+		// Blending and swizzling with crushed ice dilute at least as much
+		// as shaking.
+		return methodShaken
+	case mixStir, mixThrow:
+		return methodStirred
+	case mixBuild:
+		return methodBuilt
+	}
+	instructions := strings.ToLower(strings.Join(sp.Instructions, " "))
+	switch {
+	case strings.Contains(instructions, "shake"):
+		return methodShaken
+	case strings.Contains(instructions, "stir"):
+		return methodStirred
+	}
+	return methodBuilt
+}
+
+// dilutionFor returns the fraction of water added by mixing a drink of the
+// given undiluted ABV fraction, using Dave Arnold's empirical models for
+// shaken and stirred drinks.
+func dilutionFor(method string, abv float64) float64 {
+	switch method {
+	case methodShaken:
+		return 1.567*abv*abv + 1.742*abv + 0.203
+	case methodStirred:
+		return -1.21*abv*abv + 1.246*abv + 0.145
+	}
+	// Built drinks see a little melt before the first sip.
+	return 0.1
+}
+
+// strength is the estimated strength of a single serving.
+type strength struct {
+	// VolumeML is the final volume including dilution.
+	VolumeML float64
+	// ABV is the final ABV percentage including dilution.
+	ABV float64
+	// Dilution is the fraction of water added by mixing.
+	Dilution       float64
+	StandardDrinks float64
+	// Unmeasured counts ingredients left out because their volume is unknown.
+	Unmeasured int
+	// InParts is set when the spec is only given in parts, so the ABV is
+	// worked out from their ratios and the volume is unknown.
+	InParts bool
+}
+
+// ethanolDensity is in g/ml, a US standard drink is 14g of ethanol.
+const (
+	ethanolDensity    = 0.789
+	standardDrinkGram = 14
+)
+
+// partUnit is the unit for specs given as ratios rather than volumes.
+const partUnit = "part"
+
+func estimateStrength(v variation, method string) strength {
+	var st strength
+	var volume, alcohol float64
+	var parts, partAlcohol float64
+	var partCount int
+	for _, ing := range v {
+		if u, ok := lookupUnit(ing.Unit); ok && u.name == partUnit && ing.parsed() {
+			parts += ing.Amount
+			partAlcohol += ing.Amount * ingredientABV(ing.Name) / 100
+			partCount++
+			continue
+		}
+		ml := ing.ml()
+		if ml == 0 {
+			if !ing.parsed() {
+				st.Unmeasured++
+			}
+			continue
+		}
+		volume += ml
+		alcohol += ml * ingredientABV(ing.Name) / 100
+	}
+	if volume == 0 {
+		if parts > 0 {
+			st.InParts = true
+			st.Dilution = dilutionFor(method, partAlcohol/parts)
+			st.ABV = partAlcohol / (parts * (1 + st.Dilution)) * 100
+		}
+		return st
+	}
+	// Parts can't be mixed with fixed volumes, so leave them out.
+	st.Unmeasured += partCount
+	st.Dilution = dilutionFor(method, alcohol/volume)
+	st.VolumeML = volume * (1 + st.Dilution)
+	st.ABV = alcohol / st.VolumeML * 100
+	st.StandardDrinks = alcohol * ethanolDensity / standardDrinkGram
+	return st
+}
+
+func (st strength) format(system unitSystem) string {
+	var content string
+	switch {
+	case st.InParts:
+		content = fmt.Sprintf("~%.0f%% ABV, volume unknown as the spec is in parts", st.ABV)
+	case st.VolumeML == 0:
+		content = "Strength unknown"
+	default:
+		content = fmt.Sprintf("~%.0f%% ABV, %s total, %.1f standard drinks", st.ABV, formatVolume(st.VolumeML, system), st.StandardDrinks)
+	}
+	if st.Unmeasured > 0 {
+		content += fmt.Sprintf(" (not counting %d unmeasured ingredients)", st.Unmeasured)
+	}
+	return content
+}
+
+// strengthSummary describes the strength of each variation of a spec.
+func strengthSummary(sp *spec, system unitSystem) string {
+	method := specMethod(sp)
+	if len(sp.Ingredients) == 1 {
+		return estimateStrength(sp.Ingredients[0], method).format(system)
+	}
+	var content string
+	for n, v := range sp.Ingredients {
+		content = fmt.Sprintf("%s*Variation %d:* %s\n", content, n+1, estimateStrength(v, method).format(system))
+	}
+	return content
+}
+
+func strengthFilter(ctx context.Context, store CatalogStore, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	opts := subcommandOptions(i)
+	under, over := math.Inf(1), math.Inf(-1)
+	if o, ok := opts["under"]; ok {
+		under = float64(o.IntValue())
+	}
+	if o, ok := opts["over"]; ok {
+		over = float64(o.IntValue())
+	}
+	if math.IsInf(under, 1) && math.IsInf(over, -1) {
+		respond(s, i.Interaction, "Give at least one of under or over", nil, true)
+		return
+	}
+
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: 1 << 6,
+		},
+	}); err != nil {
+		logInteractionError(s, i.Interaction, err)
+		return
+	}
+
+	var matches []string
+	for _, cocktail := range catalog.names() {
+		sp, ok := catalog.spec(cocktail)
+		if !ok {
+			continue
+		}
+		method := specMethod(sp)
+		for n, v := range sp.Ingredients {
+			st := estimateStrength(v, method)
+			if (st.VolumeML == 0 && !st.InParts) || st.ABV >= under || st.ABV <= over {
+				continue
+			}
+			name := cocktail
+			if len(sp.Ingredients) > 1 {
+				name = fmt.Sprintf("%s (variation %d)", cocktail, n+1)
+			}
+			matches = append(matches, fmt.Sprintf("%s, ~%.0f%%", name, st.ABV))
+		}
+	}
+
+	var items []string
+	for _, m := range matches {
+		items = append(items, "    "+m)
+	}
+	editPages(s, i.Interaction, fmt.Sprintf("%d cocktails matched:\n", len(matches)), items)
+}
@@ -0,0 +1,16 @@
+package main
+
+import "testing"
+
+func TestEstimateStrengthParts(t *testing.T) {
+	parts := variation{parseIngredient("2 parts gin"), parseIngredient("1 part sweet vermouth")}
+	st := estimateStrength(parts, methodStirred)
+	if !st.InParts || st.ABV <= 0 || st.VolumeML != 0 {
+		t.Errorf("estimateStrength(%v) = %+v, want an ABV from the part ratios and no volume", parts, st)
+	}
+
+	mixed := variation{parseIngredient("2 oz gin"), parseIngredient("1 part sweet vermouth")}
+	if st := estimateStrength(mixed, methodStirred); st.InParts || st.Unmeasured != 1 {
+		t.Errorf("estimateStrength(%v) = %+v, want the part counted as unmeasured", mixed, st)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// normalizeTag puts a tag in its stored form, lower case with dashes for
+// spaces.
+func normalizeTag(t string) string {
+	return strings.Join(strings.Fields(strings.ToLower(t)), "-")
+}
+
+// splitTags parses a comma separated list of tags.
+func splitTags(s string) []string {
+	var tags []string
+	for _, t := range strings.Split(s, ",") {
+		if t = normalizeTag(t); t != "" {
+			tags, _ = addUnique(tags, t)
+		}
+	}
+	return tags
+}
+
+// hasTag reports whether the spec is tagged with tag.
+func (s *spec) hasTag(tag string) bool {
+	for _, t := range s.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// tagCounts returns how many cocktails have each tag.
+func (c *catalogIndex) tagCounts() map[string]int {
+	c.RLock()
+	defer c.RUnlock()
+	counts := map[string]int{}
+	for _, sp := range c.specs {
+		for _, t := range sp.Tags {
+			counts[t]++
+		}
+	}
+	return counts
+}
+
+// sortedTags returns the known tags, most used first.
+func sortedTags() []string {
+	counts := catalog.tagCounts()
+	var tags []string
+	for t := range counts {
+		tags = append(tags, t)
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if counts[tags[i]] != counts[tags[j]] {
+			return counts[tags[i]] > counts[tags[j]]
+		}
+		return tags[i] < tags[j]
+	})
+	return tags
+}
+
+// listTags lists the known tags, or the cocktails with a tag if one is given.
+func listTags(ctx context.Context, store CatalogStore, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if opt, ok := subcommandOptions(i)["tag"]; ok {
+		tag := normalizeTag(opt.StringValue())
+		var items []string
+		for _, c := range (cocktailFilter{Tag: tag}).cocktails() {
+			items = append(items, "    "+c)
+		}
+		respondPages(s, i.Interaction, fmt.Sprintf("%d cocktails tagged %s:\n", len(items), tag), items, true)
+		return
+	}
+
+	counts := catalog.tagCounts()
+	var items []string
+	for _, t := range sortedTags() {
+		items = append(items, fmt.Sprintf("    %s (%d)", t, counts[t]))
+	}
+	respondPages(s, i.Interaction, fmt.Sprintf("%d tags in use:\n", len(items)), items, true)
+}
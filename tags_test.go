@@ -0,0 +1,57 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeTag(t *testing.T) {
+	for _, tc := range []struct {
+		tag  string
+		want string
+	}{
+		{"sour", "sour"},
+		{"Tiki", "tiki"},
+		{"  Low ABV ", "low-abv"},
+		{"low-abv", "low-abv"},
+		{"", ""},
+	} {
+		if got := normalizeTag(tc.tag); got != tc.want {
+			t.Errorf("normalizeTag(%q) = %q, want %q", tc.tag, got, tc.want)
+		}
+	}
+}
+
+func TestSplitTags(t *testing.T) {
+	for _, tc := range []struct {
+		s    string
+		want []string
+	}{
+		{"", nil},
+		{"sour", []string{"sour"}},
+		{"Sour, gin,  Low ABV", []string{"sour", "gin", "low-abv"}},
+		{"sour,,sour, SOUR", []string{"sour"}},
+		{" , ", nil},
+	} {
+		if got := splitTags(tc.s); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("splitTags(%q) = %q, want %q", tc.s, got, tc.want)
+		}
+	}
+}
+
+func TestHasTag(t *testing.T) {
+	sp := &spec{Tags: []string{"sour", "low-abv"}}
+	for _, tc := range []struct {
+		tag  string
+		want bool
+	}{
+		{"sour", true},
+		{"low-abv", true},
+		{"tiki", false},
+		{"", false},
+	} {
+		if got := sp.hasTag(tc.tag); got != tc.want {
+			t.Errorf("hasTag(%q) = %v, want %v", tc.tag, got, tc.want)
+		}
+	}
+}
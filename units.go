@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// unitSystem is the unit that pours are rendered in, small measures like
+// dashes and barspoons are always left as is.
+type unitSystem string
+
+const (
+	unitsOriginal unitSystem = "original"
+	unitsOz       unitSystem = "oz"
+	unitsML       unitSystem = "ml"
+	unitsCL       unitSystem = "cl"
+)
+
+var unitSystemChoices = []*discordgo.ApplicationCommandOptionChoice{
+	{Name: "as written", Value: string(unitsOriginal)},
+	{Name: "ounces", Value: string(unitsOz)},
+	{Name: "millilitres", Value: string(unitsML)},
+	{Name: "centilitres", Value: string(unitsCL)},
+}
+
+// pourUnits are the units converted between unit systems.
+var pourUnits = map[string]bool{"oz": true, "ml": true, "cl": true, "cup": true}
+
+func parseUnitSystem(s string) (unitSystem, bool) {
+	switch u := unitSystem(strings.ToLower(s)); u {
+	case unitsOriginal, unitsOz, unitsML, unitsCL:
+		return u, true
+	}
+	return "", false
+}
+
+// in returns the ingredient converted to the given unit system, ingredients
+// that can't be converted are returned unchanged.
+func (ing ingredient) in(system unitSystem) ingredient {
+	if system == unitsOriginal || system == "" || !ing.parsed() || !pourUnits[ing.Unit] {
+		return ing
+	}
+	ml := ing.ml()
+	switch system {
+	case unitsOz:
+		// Jiggers measure in eighths of an ounce.
+		ing.Amount = math.Round(ml/29.5735*8) / 8
+	case unitsML:
+		ing.Amount = math.Round(ml/2.5) * 2.5
+	case unitsCL:
+		ing.Amount = math.Round(ml/10*4) / 4
+	}
+	if ing.Amount == 0 {
+		return ing
+	}
+	ing.Unit = string(system)
+	ing.Raw = ing.describe()
+	return ing
+}
+
+// formatVolume formats a volume in millilitres in the given unit system,
+// showing both ounces and millilitres when there is no preference.
+func formatVolume(ml float64, system unitSystem) string {
+	oz := fmt.Sprintf("%s oz", formatOz(ml/29.5735))
+	switch system {
+	case unitsOz:
+		return oz
+	case unitsML:
+		return fmt.Sprintf("%.0f ml", ml)
+	case unitsCL:
+		return fmt.Sprintf("%s cl", formatAmount(math.Round(ml/10*4)/4))
+	}
+	return fmt.Sprintf("%s (%.0f ml)", oz, ml)
+}
+
+// formatOz formats an amount of ounces as a mixed fraction, e.g. "1 1/2".
+func formatOz(f float64) string {
+	eighths := int(math.Round(f * 8))
+	whole, rem := eighths/8, eighths%8
+	if rem == 0 {
+		return fmt.Sprint(whole)
+	}
+	num, den := rem, 8
+	for num%2 == 0 {
+		num, den = num/2, den/2
+	}
+	if whole == 0 {
+		return fmt.Sprintf("%d/%d", num, den)
+	}
+	return fmt.Sprintf("%d %d/%d", whole, num, den)
+}
+
+// unitsFor returns the unit system to render with for this interaction, using
+// the units option if given and the user's saved preference otherwise.
+func unitsFor(ctx context.Context, store CatalogStore, i *discordgo.InteractionCreate) unitSystem {
+	if opt, ok := subcommandOptions(i)["units"]; ok {
+		if u, ok := parseUnitSystem(opt.StringValue()); ok {
+			return u
+		}
+	}
+	prefs, err := getUserPrefs(ctx, store, interactionUser(i).ID)
+	if err != nil {
+		return unitsOriginal
+	}
+	if u, ok := parseUnitSystem(prefs.Units); ok {
+		return u
+	}
+	return unitsOriginal
+}
+
+func setUnits(ctx context.Context, store CatalogStore, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	system, ok := parseUnitSystem(subcommandOptions(i)["system"].StringValue())
+	if !ok {
+		respond(s, i.Interaction, "Unknown unit system", nil, true)
+		return
+	}
+	user := interactionUser(i)
+	prefs, err := getUserPrefs(ctx, store, user.ID)
+	if err != nil {
+		logInteractionError(s, i.Interaction, err)
+		return
+	}
+	prefs.Units = string(system)
+	if err := saveUserPrefs(ctx, store, user.ID, prefs); err != nil {
+		logInteractionError(s, i.Interaction, err)
+		return
+	}
+	respond(s, i.Interaction, fmt.Sprintf("Specs will now be shown in %s", system), nil, true)
+}
@@ -0,0 +1,82 @@
+package main
+
+import "testing"
+
+func TestIngredientIn(t *testing.T) {
+	for _, tc := range []struct {
+		raw    string
+		system unitSystem
+		want   string
+	}{
+		{"2 oz gin", unitsML, "60 ml gin"},
+		{"30 ml gin", unitsOz, "1 oz gin"},
+		{"45 ml rum", unitsCL, "4.5 cl rum"},
+		{"1 cup milk", unitsOz, "8 oz milk"},
+		{"3/4 oz lime juice", unitsML, "22.5 ml lime juice"},
+		{"1 oz gin", unitsOriginal, "1 oz gin"},
+		{"1 oz gin", "", "1 oz gin"},
+		{"2 dashes bitters", unitsML, "2 dashes bitters"},
+		{"1 barspoon maraschino", unitsOz, "1 barspoon maraschino"},
+		{"top with soda", unitsOz, "top with soda"},
+		// Too little to show in the unit system, so left as written.
+		{"0.1 ml gin", unitsOz, "0.1 ml gin"},
+	} {
+		if got := parseIngredient(tc.raw).in(tc.system).Raw; got != tc.want {
+			t.Errorf("%q in %q = %q, want %q", tc.raw, tc.system, got, tc.want)
+		}
+	}
+}
+
+func TestFormatOz(t *testing.T) {
+	for _, tc := range []struct {
+		oz   float64
+		want string
+	}{
+		{2, "2"},
+		{1.5, "1 1/2"},
+		{0.25, "1/4"},
+		{0.75, "3/4"},
+		{0.125, "1/8"},
+		{1.0625, "1 1/8"},
+		{0.05, "0"},
+	} {
+		if got := formatOz(tc.oz); got != tc.want {
+			t.Errorf("formatOz(%v) = %q, want %q", tc.oz, got, tc.want)
+		}
+	}
+}
+
+func TestFormatVolume(t *testing.T) {
+	for _, tc := range []struct {
+		system unitSystem
+		want   string
+	}{
+		{unitsOriginal, "2 oz (59 ml)"},
+		{unitsOz, "2 oz"},
+		{unitsML, "59 ml"},
+		{unitsCL, "6 cl"},
+	} {
+		if got := formatVolume(59.147, tc.system); got != tc.want {
+			t.Errorf("formatVolume(59.147, %q) = %q, want %q", tc.system, got, tc.want)
+		}
+	}
+}
+
+func TestParseUnitSystem(t *testing.T) {
+	for _, tc := range []struct {
+		s    string
+		want unitSystem
+		ok   bool
+	}{
+		{"ml", unitsML, true},
+		{"ML", unitsML, true},
+		{"original", unitsOriginal, true},
+		{"as written", "", false},
+		{"", "", false},
+	} {
+		got, ok := parseUnitSystem(tc.s)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("parseUnitSystem(%q) = %q, %v, want %q, %v", tc.s, got, ok, tc.want, tc.ok)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"path"
+)
+
+var usersPrefix = path.Join(metaPrefix, "users")
+
+// userPrefs are the per user settings, stored at _meta/users/<id>/prefs.
+type userPrefs struct {
+	Units string `json:",omitempty"`
+}
+
+func getUserPrefs(ctx context.Context, store CatalogStore, id string) (*userPrefs, error) {
+	var prefs userPrefs
+	data, err := store.ReadObject(ctx, path.Join(usersPrefix, id, "prefs"))
+	if err == errNotFound {
+		return &prefs, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &prefs, json.Unmarshal(data, &prefs)
+}
+
+func saveUserPrefs(ctx context.Context, store CatalogStore, id string, prefs *userPrefs) error {
+	data, err := json.Marshal(prefs)
+	if err != nil {
+		return err
+	}
+	return store.WriteObject(ctx, path.Join(usersPrefix, id, "prefs"), data)
+}